@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"task_manager1/Domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead is the room left for multipart headers and boundaries
+// on top of the largest allowed file.
+const multipartOverhead = 1 << 20
+
+func toAttachmentResponse(a Domain.Attachment) Domain.AttachmentResponse {
+	return Domain.AttachmentResponse{
+		ID:          a.ID.Hex(),
+		Name:        a.Name,
+		Size:        a.Size,
+		ContentType: a.ContentType,
+		SHA256:      a.SHA256,
+		UploadedBy:  a.UploadedBy,
+		UploadedAt:  a.UploadedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// UploadAttachment attaches the "file" part of a multipart/form-data body to
+// a task (owner or admin). The file is streamed to storage, not buffered.
+func (ctl *Controller) UploadAttachment(c *gin.Context) {
+	ifVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	limit := ctl.TaskUC.AttachmentPolicy().MaxSize
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+multipartOverhead)
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "multipart/form-data body required"})
+		return
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file part required"})
+			return
+		}
+		if err != nil {
+			writeUploadError(c, err)
+			return
+		}
+		if part.FormName() != "file" {
+			_ = part.Close()
+			continue
+		}
+		ctx := requestContext(c)
+		att, err := ctl.TaskUC.AddAttachment(ctx, actorFromContext(c), c.Param("id"), part.FileName(), part.Header.Get("Content-Type"), part, ifVersion)
+		if err != nil {
+			writeUploadError(c, err)
+			return
+		}
+		if att.ID.IsZero() {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		c.JSON(http.StatusCreated, toAttachmentResponse(att))
+		return
+	}
+}
+
+// writeUploadError maps upload failures, including a body cut off by the
+// request size limit, to HTTP responses
+func writeUploadError(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge), errors.Is(err, Domain.ErrAttachmentTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": Domain.ErrAttachmentTooLarge.Error()})
+	case errors.Is(err, Domain.ErrUnsupportedMediaType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	default:
+		writeTaskError(c, err, "failed to upload attachment")
+	}
+}
+
+// DownloadAttachment streams an attachment of a visible task
+func (ctl *Controller) DownloadAttachment(c *gin.Context) {
+	ctx := requestContext(c)
+	att, body, err := ctl.TaskUC.OpenAttachment(ctx, actorFromContext(c), c.Param("id"), c.Param("attachment"))
+	if err != nil {
+		writeTaskError(c, err, "failed to download attachment")
+		return
+	}
+	if att.ID.IsZero() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+	defer body.Close()
+	c.DataFromReader(http.StatusOK, att.Size, att.ContentType, body, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": att.Name}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// DeleteAttachment removes an attachment from a task (owner or admin)
+func (ctl *Controller) DeleteAttachment(c *gin.Context) {
+	ifVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	ctx := requestContext(c)
+	updated, err := ctl.TaskUC.RemoveAttachment(ctx, actorFromContext(c), c.Param("id"), c.Param("attachment"), ifVersion)
+	if err != nil {
+		writeTaskError(c, err, "failed to delete attachment")
+		return
+	}
+	if updated.ID.IsZero() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+	c.Header("ETag", etag(updated.Version))
+	c.JSON(http.StatusOK, ctl.taskResponse(updated))
+}
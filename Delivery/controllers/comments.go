@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"task_manager1/Domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+func toCommentResponse(c Domain.Comment) Domain.CommentResponse {
+	resp := Domain.CommentResponse{
+		ID:        c.ID.Hex(),
+		TaskID:    c.TaskID.Hex(),
+		Author:    c.Author,
+		Body:      c.Body,
+		Mentions:  c.Mentions,
+		CreatedAt: c.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if resp.Mentions == nil {
+		resp.Mentions = []string{}
+	}
+	if c.EditedAt != nil {
+		resp.EditedAt = c.EditedAt.UTC().Format(time.RFC3339)
+	}
+	return resp
+}
+
+// GetComments lists the comment thread of a task, oldest first
+func (ctl *Controller) GetComments(c *gin.Context) {
+	ctx := requestContext(c)
+	comments, err := ctl.TaskUC.Comments(ctx, actorFromContext(c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch comments"})
+		return
+	}
+	if comments == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+	resp := make([]Domain.CommentResponse, 0, len(comments))
+	for _, comment := range comments {
+		resp = append(resp, toCommentResponse(comment))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// AddComment posts a comment on a task; @username mentions must name existing users
+func (ctl *Controller) AddComment(c *gin.Context) {
+	var input Domain.CommentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+	ctx := requestContext(c)
+	comment, err := ctl.TaskUC.AddComment(ctx, actorFromContext(c), c.Param("id"), input)
+	if err != nil {
+		writeTaskError(c, err, "failed to add comment")
+		return
+	}
+	if comment.ID.IsZero() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+	c.JSON(http.StatusCreated, toCommentResponse(comment))
+}
+
+// EditComment replaces the body of a comment (author only)
+func (ctl *Controller) EditComment(c *gin.Context) {
+	var input Domain.CommentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+	ctx := requestContext(c)
+	comment, err := ctl.TaskUC.EditComment(ctx, actorFromContext(c), c.Param("id"), c.Param("comment"), input)
+	if err != nil {
+		writeTaskError(c, err, "failed to edit comment")
+		return
+	}
+	if comment.ID.IsZero() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "comment not found"})
+		return
+	}
+	c.JSON(http.StatusOK, toCommentResponse(comment))
+}
+
+// DeleteComment removes a comment (author or admin)
+func (ctl *Controller) DeleteComment(c *gin.Context) {
+	ctx := requestContext(c)
+	ok, err := ctl.TaskUC.DeleteComment(ctx, actorFromContext(c), c.Param("id"), c.Param("comment"))
+	if err != nil {
+		writeTaskError(c, err, "failed to delete comment")
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "comment not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "comment deleted"})
+}
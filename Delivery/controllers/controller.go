@@ -2,50 +2,193 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"task_manager1/Domain"
 	"task_manager1/Infrastructure/auth"
 	"task_manager1/Usecases"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // Controller holds Usecases and services
 type Controller struct {
-	UserUC *Usecases.UserUsecase
-	TaskUC *Usecases.TaskUsecase
-	JWT    *auth.JWTService
+	UserUC    *Usecases.UserUsecase
+	TaskUC    *Usecases.TaskUsecase
+	ProjectUC *Usecases.ProjectUsecase
+	JWT       *auth.JWTService
+	Revoked   auth.RevocationStore
 }
 
 // NewController constructs controller
-func NewController(userUC *Usecases.UserUsecase, taskUC *Usecases.TaskUsecase, jwt *auth.JWTService) *Controller {
-	return &Controller{UserUC: userUC, TaskUC: taskUC, JWT: jwt}
+func NewController(userUC *Usecases.UserUsecase, taskUC *Usecases.TaskUsecase, projectUC *Usecases.ProjectUsecase, jwt *auth.JWTService, revoked auth.RevocationStore) *Controller {
+	return &Controller{UserUC: userUC, TaskUC: taskUC, ProjectUC: projectUC, JWT: jwt, Revoked: revoked}
+}
+
+// actorFromContext builds the acting user from the principal set by AuthMiddleware
+func actorFromContext(c *gin.Context) Domain.Actor {
+	p, _ := auth.PrincipalFrom(c)
+	return Domain.Actor{UserID: p.UserID, Username: p.Username, Role: p.Role}
+}
+
+// requestContext scopes the request to the organisation of the caller's
+// token. Without one the context stays unscoped and repositories refuse it.
+func requestContext(c *gin.Context) context.Context {
+	ctx := context.Background()
+	if p, ok := auth.PrincipalFrom(c); ok {
+		ctx = Domain.WithTenant(ctx, p.TenantID)
+	}
+	return ctx
+}
+
+// writeTaskError maps usecase errors to HTTP responses, falling back to a
+// 500 with the given message for anything unexpected
+func writeTaskError(c *gin.Context, err error, fallback string) {
+	var transition *Domain.TransitionError
+	switch {
+	case errors.As(err, &transition):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "allowed_transitions": transition.Allowed})
+	case errors.Is(err, Domain.ErrUnknownStatus):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, Domain.ErrForbidden),
+		errors.Is(err, Domain.ErrNotCommentAuthor),
+		errors.Is(err, Domain.ErrNotProjectOwner),
+		errors.Is(err, Domain.ErrOwnAccount):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, Domain.ErrNotInTrash),
+		errors.Is(err, Domain.ErrTagExists),
+		errors.Is(err, Domain.ErrProjectNotEmpty),
+		errors.Is(err, Domain.ErrLastProjectOwner),
+		errors.Is(err, Domain.ErrDependencyCycle),
+		errors.Is(err, Domain.ErrTaskBlocked),
+		errors.Is(err, Domain.ErrEditConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, Domain.ErrVersionMismatch):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+	case errors.Is(err, Domain.ErrUserNotFound),
+		errors.Is(err, Domain.ErrChecklistItemNotFound),
+		errors.Is(err, Domain.ErrAttachmentNotFound),
+		errors.Is(err, Domain.ErrProjectNotFound),
+		errors.Is(err, Domain.ErrOrganizationNotFound),
+		errors.Is(err, Domain.ErrDependencyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, Domain.ErrTitleRequired),
+		errors.Is(err, Domain.ErrInvalidDueDate),
+		errors.Is(err, Domain.ErrInvalidQuery),
+		errors.Is(err, Domain.ErrInvalidCursor),
+		errors.Is(err, Domain.ErrInvalidPatch),
+		errors.Is(err, Domain.ErrInvalidParent),
+		errors.Is(err, Domain.ErrInvalidChecklist),
+		errors.Is(err, Domain.ErrInvalidDependency),
+		errors.Is(err, Domain.ErrInvalidRecurrence),
+		errors.Is(err, Domain.ErrInvalidTag),
+		errors.Is(err, Domain.ErrInvalidPriority),
+		errors.Is(err, Domain.ErrInvalidComment),
+		errors.Is(err, Domain.ErrUnknownMention),
+		errors.Is(err, Domain.ErrInvalidAttachment),
+		errors.Is(err, Domain.ErrInvalidProject),
+		errors.Is(err, Domain.ErrInvalidOrganization),
+		errors.Is(err, Domain.ErrUnknownTag),
+		errors.Is(err, Domain.ErrNoFieldsToUpdate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// taskResponse maps a task to its API representation, including the
+// statuses the workflow allows next
+func (ctl *Controller) taskResponse(t Domain.Task) Domain.TaskResponse {
+	resp := toTaskResponse(t)
+	resp.AllowedTransitions = ctl.TaskUC.Workflow().Next(t.Status)
+	return resp
+}
+
+// toTaskResponse maps a task entity to its API representation
+func toTaskResponse(t Domain.Task) Domain.TaskResponse {
+	resp := Domain.TaskResponse{
+		Title:       t.Title,
+		Description: t.Description,
+		Status:      t.Status,
+		Priority:    t.Priority,
+		Version:     t.Version,
+		CreatedBy:   t.CreatedBy,
+		UpdatedBy:   t.UpdatedBy,
+		DeletedBy:   t.DeletedBy,
+		Checklist:   t.Checklist,
+		Progress:    t.Progress(),
+		Blocked:     t.Blocked,
+	}
+	if !t.CreatedAt.IsZero() {
+		resp.CreatedAt = t.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	if !t.UpdatedAt.IsZero() {
+		resp.UpdatedAt = t.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+	if t.DeletedAt != nil {
+		resp.DeletedAt = t.DeletedAt.UTC().Format(time.RFC3339)
+	}
+	if t.DueDate != nil {
+		resp.DueDate = t.DueDate.UTC().Format(time.RFC3339)
+	}
+	if !t.ID.IsZero() {
+		resp.ID = t.ID.Hex()
+	}
+	if !t.CreatorID.IsZero() {
+		resp.CreatorID = t.CreatorID.Hex()
+	}
+	if !t.OwnerID.IsZero() {
+		resp.OwnerID = t.OwnerID.Hex()
+	}
+	if !t.ProjectID.IsZero() {
+		resp.ProjectID = t.ProjectID.Hex()
+	}
+	if !t.ParentID.IsZero() {
+		resp.ParentID = t.ParentID.Hex()
+	}
+	if t.Recurrence != nil {
+		resp.Recurrence = t.Recurrence.Rule
+		resp.SeriesID = t.Recurrence.SeriesID.Hex()
+		resp.Occurrence = t.Recurrence.Index
+	}
+	for _, id := range t.SharedWith {
+		resp.SharedWith = append(resp.SharedWith, id.Hex())
+	}
+	for _, id := range t.BlockedBy {
+		resp.BlockedBy = append(resp.BlockedBy, id.Hex())
+	}
+	resp.Assignees = t.Assignees
+	resp.Tags = t.Tags
+	for _, a := range t.Attachments {
+		resp.Attachments = append(resp.Attachments, toAttachmentResponse(a))
+	}
+	return resp
 }
 
 // Register endpoint
 func (ctl *Controller) Register(c *gin.Context) {
 	var body struct {
-		Username string `json:"username" binding:"required"`
-		Password string `json:"password" binding:"required"`
+		Username     string `json:"username" binding:"required"`
+		Password     string `json:"password" binding:"required"`
+		Organization string `json:"organization" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "username and password required"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "username, password and organization required"})
 		return
 	}
 	ctx := context.Background()
-	u, err := ctl.UserUC.Register(ctx, body.Username, body.Password)
+	u, err := ctl.UserUC.Register(ctx, body.Username, body.Password, body.Organization)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	// generate token
-	token, err := ctl.JWT.GenerateToken(u.Username, u.Role)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
-		return
-	}
-	c.JSON(http.StatusCreated, gin.H{"username": u.Username, "role": u.Role, "token": token})
+	ctl.startSession(c, ctx, http.StatusCreated, u)
 }
 
 // Login endpoint
@@ -64,12 +207,7 @@ func (ctl *Controller) Login(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
 	}
-	token, err := ctl.JWT.GenerateToken(u.Username, u.Role)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{"username": u.Username, "role": u.Role, "token": token})
+	ctl.startSession(c, ctx, http.StatusOK, u)
 }
 
 // Promote endpoint (admin)
@@ -79,7 +217,7 @@ func (ctl *Controller) Promote(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "username required"})
 		return
 	}
-	ctx := context.Background()
+	ctx := requestContext(c)
 	updated, err := ctl.UserUC.Promote(ctx, username)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to promote"})
@@ -92,36 +230,162 @@ func (ctl *Controller) Promote(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"username": updated.Username, "role": updated.Role})
 }
 
-// GetTasks (authenticated)
-func (ctl *Controller) GetTasks(c *gin.Context) {
-	ctx := context.Background()
-	tasks, err := ctl.TaskUC.List(ctx)
+// taskFilterFromQuery reads the list query parameters shared by GET /tasks
+// and GET /tasks/overdue. It writes a 400 response and returns false on bad input.
+func (ctl *Controller) taskFilterFromQuery(c *gin.Context, actor Domain.Actor) (Domain.TaskFilter, bool) {
+	f := Domain.TaskFilter{
+		Assignee:      c.Query("assignee"),
+		Status:        c.Query("status"),
+		Priority:      c.Query("priority"),
+		TitleContains: c.Query("title"),
+		Tags:          c.QueryArray("tag"),
+		TagMatch:      c.Query("tag_match"),
+		Cursor:        c.Query("cursor"),
+	}
+	if f.Assignee == "me" {
+		f.Assignee = actor.Username
+	}
+	if project := c.Query("project"); project != "" {
+		oid, err := primitive.ObjectIDFromHex(project)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "project must be a project id"})
+			return f, false
+		}
+		f.ProjectID = oid
+	}
+	if sort := c.Query("sort"); sort != "" {
+		f.SortDesc = strings.HasPrefix(sort, "-")
+		f.SortBy = strings.TrimPrefix(sort, "-")
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return f, false
+		}
+		f.Limit = n
+	}
+	f.CreatedBy = c.Query("created_by")
+	f.UpdatedBy = c.Query("updated_by")
+	bounds := []struct {
+		name     string
+		endOfDay bool
+		dest     **time.Time
+	}{
+		{"due_after", false, &f.DueAfter},
+		{"due_before", true, &f.DueBefore},
+		{"created_after", false, &f.CreatedAfter},
+		{"created_before", true, &f.CreatedBefore},
+		{"updated_after", false, &f.UpdatedAfter},
+		{"updated_before", true, &f.UpdatedBefore},
+	}
+	var loc *time.Location
+	for _, b := range bounds {
+		value := c.Query(b.name)
+		if value == "" {
+			continue
+		}
+		if loc == nil {
+			var err error
+			if loc, err = ctl.TaskUC.Location(requestContext(c), actor); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch tasks"})
+				return f, false
+			}
+		}
+		ts, err := Usecases.ParseDateBound(b.name, value, loc, b.endOfDay)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return f, false
+		}
+		*b.dest = &ts
+	}
+	return f, true
+}
+
+// writeTaskPage renders a page of tasks in the list envelope
+func (ctl *Controller) writeTaskPage(c *gin.Context, f Domain.TaskFilter, page Domain.TaskPage, err error) {
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch tasks"})
+		writeTaskError(c, err, "failed to fetch tasks")
 		return
 	}
-	resp := []Domain.TaskResponse{}
-	for _, t := range tasks {
-		id := ""
-		if !t.ID.IsZero() {
-			id = t.ID.Hex()
-		}
-		resp = append(resp, Domain.TaskResponse{
-			ID:          id,
-			Title:       t.Title,
-			Description: t.Description,
-			DueDate:     t.DueDate,
-			Status:      t.Status,
-		})
+	resp := Domain.TaskListResponse{
+		Data: []Domain.TaskResponse{},
+		Pagination: Domain.PageInfo{
+			Limit:      f.Limit,
+			NextCursor: page.NextCursor,
+			HasMore:    page.HasMore,
+		},
+	}
+	if resp.Pagination.Limit == 0 {
+		resp.Pagination.Limit = Domain.DefaultPageLimit
+	}
+	for _, t := range page.Tasks {
+		resp.Data = append(resp.Data, ctl.taskResponse(t))
 	}
 	c.JSON(http.StatusOK, resp)
 }
 
+// GetTasks (authenticated; non-admins only see the tasks of their projects
+// and tasks shared with or assigned to them)
+// Query: project, assignee=me|<username>, status, priority, title, tag
+// (repeatable) with tag_match=all|any, due_after, due_before, created_after,
+// created_before, updated_after, updated_before, created_by, updated_by,
+// sort=[-]priority|[-]created_at|[-]updated_at|[-]due_date|[-]deleted_at
+// (default -priority, then due date), limit, cursor.
+func (ctl *Controller) GetTasks(c *gin.Context) {
+	actor := actorFromContext(c)
+	f, ok := ctl.taskFilterFromQuery(c, actor)
+	if !ok {
+		return
+	}
+	ctx := requestContext(c)
+	page, err := ctl.TaskUC.List(ctx, actor, f)
+	ctl.writeTaskPage(c, f, page, err)
+}
+
+// GetOverdueTasks lists visible tasks past their due date that are not done
+func (ctl *Controller) GetOverdueTasks(c *gin.Context) {
+	actor := actorFromContext(c)
+	f, ok := ctl.taskFilterFromQuery(c, actor)
+	if !ok {
+		return
+	}
+	ctx := requestContext(c)
+	page, err := ctl.TaskUC.Overdue(ctx, actor, f)
+	ctl.writeTaskPage(c, f, page, err)
+}
+
+// SetTimezone stores the caller's time zone for date-only due dates
+func (ctl *Controller) SetTimezone(c *gin.Context) {
+	var body struct {
+		Timezone string `json:"timezone" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "timezone required"})
+		return
+	}
+	ctx := requestContext(c)
+	updated, err := ctl.UserUC.SetTimezone(ctx, actorFromContext(c).Username, body.Timezone)
+	if err != nil {
+		if errors.Is(err, Domain.ErrInvalidTimezone) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update timezone"})
+		return
+	}
+	if updated.Username == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"username": updated.Username, "timezone": updated.Timezone})
+}
+
 // GetTaskByID (authenticated)
 func (ctl *Controller) GetTaskByID(c *gin.Context) {
 	id := c.Param("id")
-	ctx := context.Background()
-	t, err := ctl.TaskUC.GetByID(ctx, id)
+	ctx := requestContext(c)
+	t, err := ctl.TaskUC.GetByID(ctx, actorFromContext(c), id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch task"})
 		return
@@ -130,80 +394,245 @@ func (ctl *Controller) GetTaskByID(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
 		return
 	}
-	c.JSON(http.StatusOK, Domain.TaskResponse{
-		ID:          t.ID.Hex(),
-		Title:       t.Title,
-		Description: t.Description,
-		DueDate:     t.DueDate,
-		Status:      t.Status,
-	})
+	c.Header("ETag", etag(t.Version))
+	if noneMatch(c, t.Version) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, ctl.taskResponse(t))
 }
 
-// CreateTask (admin)
+// GetTaskHistory lists the changes made to a task. With ?at=<RFC 3339> it
+// returns the task as it was at that moment instead.
+func (ctl *Controller) GetTaskHistory(c *gin.Context) {
+	id := c.Param("id")
+	ctx := requestContext(c)
+	actor := actorFromContext(c)
+	if at := c.Query("at"); at != "" {
+		ts, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "at must be an RFC 3339 timestamp"})
+			return
+		}
+		e, err := ctl.TaskUC.StateAt(ctx, actor, id, ts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch task history"})
+			return
+		}
+		if e.TaskID.IsZero() {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found at that time"})
+			return
+		}
+		c.JSON(http.StatusOK, Domain.TaskStateResponse{
+			At:      e.At.UTC().Format(time.RFC3339),
+			Deleted: e.Snapshot.IsDeleted() || e.Action == Domain.HistoryPurged,
+			Task:    toTaskResponse(e.Snapshot),
+		})
+		return
+	}
+	entries, err := ctl.TaskUC.History(ctx, actor, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch task history"})
+		return
+	}
+	if entries == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+	resp := make([]Domain.TaskHistoryResponse, 0, len(entries))
+	for _, e := range entries {
+		changes := e.Changes
+		if changes == nil {
+			changes = []Domain.FieldChange{}
+		}
+		resp = append(resp, Domain.TaskHistoryResponse{
+			Action:  e.Action,
+			Actor:   e.Actor,
+			At:      e.At.UTC().Format(time.RFC3339),
+			Version: e.Version,
+			Changes: changes,
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreateTask (authenticated; caller becomes the owner)
 func (ctl *Controller) CreateTask(c *gin.Context) {
-	var input Domain.Task
+	var input Domain.TaskInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
 		return
 	}
-	ctx := context.Background()
-	created, err := ctl.TaskUC.Create(ctx, input)
+	ctx := requestContext(c)
+	created, err := ctl.TaskUC.Create(ctx, actorFromContext(c), input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create task"})
+		writeTaskError(c, err, "failed to create task")
 		return
 	}
-	c.JSON(http.StatusCreated, Domain.TaskResponse{
-		ID:          created.ID.Hex(),
-		Title:       created.Title,
-		Description: created.Description,
-		DueDate:     created.DueDate,
-		Status:      created.Status,
-	})
+	c.Header("ETag", etag(created.Version))
+	c.JSON(http.StatusCreated, ctl.taskResponse(created))
 }
 
-// UpdateTask (admin)
+// UpdateTask replaces a task (owner or admin)
 func (ctl *Controller) UpdateTask(c *gin.Context) {
 	id := c.Param("id")
-	var input Domain.Task
+	var input Domain.TaskInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
 		return
 	}
-	ctx := context.Background()
-	updated, err := ctl.TaskUC.Update(ctx, id, input)
+	ifVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	ctx := requestContext(c)
+	updated, err := ctl.TaskUC.Update(ctx, actorFromContext(c), id, input, ifVersion)
 	if err != nil {
-		if err.Error() == "no fields to update" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "no fields to update"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update"})
+		writeTaskError(c, err, "failed to update")
+		return
+	}
+	if updated.ID.IsZero() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+	c.Header("ETag", etag(updated.Version))
+	c.JSON(http.StatusOK, ctl.taskResponse(updated))
+}
+
+// PatchTask applies a JSON Merge Patch (RFC 7396) to a task (owner or admin)
+func (ctl *Controller) PatchTask(c *gin.Context) {
+	id := c.Param("id")
+	switch c.ContentType() {
+	case "application/merge-patch+json", "application/json":
+	default:
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "content type must be application/merge-patch+json"})
+		return
+	}
+	var patch map[string]json.RawMessage
+	if err := json.NewDecoder(c.Request.Body).Decode(&patch); err != nil || patch == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "merge patch must be a json object"})
+		return
+	}
+	ifVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	ctx := requestContext(c)
+	updated, err := ctl.TaskUC.Patch(ctx, actorFromContext(c), id, patch, ifVersion)
+	if err != nil {
+		writeTaskError(c, err, "failed to update")
 		return
 	}
 	if updated.ID.IsZero() {
 		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
 		return
 	}
-	c.JSON(http.StatusOK, Domain.TaskResponse{
-		ID:          updated.ID.Hex(),
-		Title:       updated.Title,
-		Description: updated.Description,
-		DueDate:     updated.DueDate,
-		Status:      updated.Status,
-	})
+	c.Header("ETag", etag(updated.Version))
+	c.JSON(http.StatusOK, ctl.taskResponse(updated))
 }
 
-// DeleteTask (admin)
+// DeleteTask (owner or admin)
 func (ctl *Controller) DeleteTask(c *gin.Context) {
 	id := c.Param("id")
-	ctx := context.Background()
-	ok, err := ctl.TaskUC.Delete(ctx, id)
+	ifVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	ctx := requestContext(c)
+	ok, err := ctl.TaskUC.Delete(ctx, actorFromContext(c), id, ifVersion)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete"})
+		writeTaskError(c, err, "failed to delete")
 		return
 	}
 	if !ok {
 		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "task deleted"})
+	c.JSON(http.StatusOK, gin.H{"message": "task moved to trash"})
+}
+
+// GetTrash lists deleted tasks that have not been purged yet. It accepts the
+// GET /tasks query parameters and sorts by -deleted_at by default.
+func (ctl *Controller) GetTrash(c *gin.Context) {
+	actor := actorFromContext(c)
+	f, ok := ctl.taskFilterFromQuery(c, actor)
+	if !ok {
+		return
+	}
+	ctx := requestContext(c)
+	page, err := ctl.TaskUC.Trash(ctx, actor, f)
+	ctl.writeTaskPage(c, f, page, err)
+}
+
+// RestoreTask takes a task out of the trash (owner or admin)
+func (ctl *Controller) RestoreTask(c *gin.Context) {
+	id := c.Param("id")
+	ctx := requestContext(c)
+	restored, err := ctl.TaskUC.Restore(ctx, actorFromContext(c), id)
+	if err != nil {
+		writeTaskError(c, err, "failed to restore task")
+		return
+	}
+	if restored.ID.IsZero() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+	c.Header("ETag", etag(restored.Version))
+	c.JSON(http.StatusOK, ctl.taskResponse(restored))
+}
+
+// AssignTask adds a user to a task's assignees (owner or admin)
+func (ctl *Controller) AssignTask(c *gin.Context) {
+	id := c.Param("id")
+	var body struct {
+		Username string `json:"username" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "username required"})
+		return
+	}
+	ctx := requestContext(c)
+	updated, err := ctl.TaskUC.Assign(ctx, actorFromContext(c), id, body.Username)
+	ctl.respondAssignment(c, updated, err)
+}
+
+// UnassignTask removes a user from a task's assignees (owner or admin)
+func (ctl *Controller) UnassignTask(c *gin.Context) {
+	id := c.Param("id")
+	ctx := requestContext(c)
+	updated, err := ctl.TaskUC.Unassign(ctx, actorFromContext(c), id, c.Param("username"))
+	ctl.respondAssignment(c, updated, err)
+}
+
+func (ctl *Controller) respondAssignment(c *gin.Context, updated Domain.Task, err error) {
+	if err != nil {
+		writeTaskError(c, err, "failed to update assignees")
+		return
+	}
+	if updated.ID.IsZero() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+	c.JSON(http.StatusOK, ctl.taskResponse(updated))
+}
+
+// ReprioritizeTasks sets the priority of many tasks at once (admin)
+func (ctl *Controller) ReprioritizeTasks(c *gin.Context) {
+	var body Domain.PriorityUpdate
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+	ctx := requestContext(c)
+	result, err := ctl.TaskUC.Reprioritize(ctx, actorFromContext(c), body)
+	if err != nil {
+		writeTaskError(c, err, "failed to update priorities")
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetWorkflow returns the task statuses and allowed transitions
+func (ctl *Controller) GetWorkflow(c *gin.Context) {
+	c.JSON(http.StatusOK, ctl.TaskUC.Workflow())
 }
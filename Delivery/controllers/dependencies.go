@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"net/http"
+
+	"task_manager1/Domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AddDependency marks another task as blocking this one (owner or admin)
+func (ctl *Controller) AddDependency(c *gin.Context) {
+	var body struct {
+		BlockedBy string `json:"blocked_by" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "blocked_by required"})
+		return
+	}
+	ctx := requestContext(c)
+	updated, err := ctl.TaskUC.AddDependency(ctx, actorFromContext(c), c.Param("id"), body.BlockedBy)
+	ctl.respondDependency(c, updated, err)
+}
+
+// RemoveDependency unlinks a blocking task (owner or admin)
+func (ctl *Controller) RemoveDependency(c *gin.Context) {
+	ctx := requestContext(c)
+	updated, err := ctl.TaskUC.RemoveDependency(ctx, actorFromContext(c), c.Param("id"), c.Param("blocker"))
+	ctl.respondDependency(c, updated, err)
+}
+
+func (ctl *Controller) respondDependency(c *gin.Context, updated Domain.Task, err error) {
+	if err != nil {
+		writeTaskError(c, err, "failed to update dependencies")
+		return
+	}
+	if updated.ID.IsZero() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+	c.Header("ETag", etag(updated.Version))
+	c.JSON(http.StatusOK, ctl.taskResponse(updated))
+}
+
+// GetTaskGraph returns the transitive dependency graph around a task
+func (ctl *Controller) GetTaskGraph(c *gin.Context) {
+	ctx := requestContext(c)
+	g, err := ctl.TaskUC.Graph(ctx, actorFromContext(c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build dependency graph"})
+		return
+	}
+	if g.Root.IsZero() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+	resp := Domain.TaskGraphResponse{
+		Root:      g.Root.Hex(),
+		Nodes:     []Domain.TaskGraphNode{},
+		Edges:     []Domain.TaskGraphEdge{},
+		Truncated: g.Truncated,
+	}
+	for _, t := range g.Nodes {
+		resp.Nodes = append(resp.Nodes, Domain.TaskGraphNode{ID: t.ID.Hex(), Title: t.Title, Status: t.Status, Blocked: t.Blocked})
+	}
+	for _, e := range g.Edges {
+		resp.Edges = append(resp.Edges, Domain.TaskGraphEdge{From: e.From.Hex(), To: e.To.Hex()})
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"task_manager1/Domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag renders a task version as a strong entity tag
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ifMatchVersion reads the If-Match header. It returns nil when the header is
+// absent or "*", and writes a 400 response and returns false when it is not a
+// single entity tag produced by etag. If-Match compares strongly, so a weak
+// tag never matches and gets a 412.
+func ifMatchVersion(c *gin.Context) (*int64, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+	tag, weak := strings.CutPrefix(header, "W/")
+	v, err := strconv.ParseInt(strings.Trim(tag, `"`), 10, 64)
+	if err != nil || strings.Contains(header, ",") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "If-Match must be a single ETag"})
+		return nil, false
+	}
+	if weak {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": Domain.ErrVersionMismatch.Error()})
+		return nil, false
+	}
+	return &v, true
+}
+
+// noneMatch reports whether If-None-Match lists the given version (or "*")
+func noneMatch(c *gin.Context, version int64) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+	current := etag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
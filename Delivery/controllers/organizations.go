@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"task_manager1/Domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetOrganization returns the caller's organisation
+func (ctl *Controller) GetOrganization(c *gin.Context) {
+	ctx := requestContext(c)
+	org, err := ctl.UserUC.Organization(ctx)
+	if err != nil {
+		writeTaskError(c, err, "failed to fetch organization")
+		return
+	}
+	resp := Domain.OrganizationResponse{ID: org.ID.Hex(), Name: org.Name, CreatedBy: org.CreatedBy}
+	if !org.CreatedAt.IsZero() {
+		resp.CreatedAt = org.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreateUser adds a user to the admin's organisation (admin)
+func (ctl *Controller) CreateUser(c *gin.Context) {
+	var body struct {
+		Username string `json:"username" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "username and password required"})
+		return
+	}
+	ctx := requestContext(c)
+	u, err := ctl.UserUC.CreateMember(ctx, body.Username, body.Password)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": u.ID.Hex(), "username": u.Username, "role": u.Role, "organization_id": u.TenantID.Hex()})
+}
+
+// Demote takes the admin role away from a user (admin)
+func (ctl *Controller) Demote(c *gin.Context) {
+	ctx := requestContext(c)
+	updated, err := ctl.UserUC.Demote(ctx, actorFromContext(c), c.Param("username"))
+	respondUser(c, updated, err, "failed to demote")
+}
+
+// DisableUser blocks a user from signing in and ends their sessions (admin)
+func (ctl *Controller) DisableUser(c *gin.Context) {
+	ctx := requestContext(c)
+	updated, err := ctl.UserUC.SetDisabled(ctx, actorFromContext(c), c.Param("username"), true)
+	respondUser(c, updated, err, "failed to disable user")
+}
+
+// EnableUser lets a disabled user sign in again (admin)
+func (ctl *Controller) EnableUser(c *gin.Context) {
+	ctx := requestContext(c)
+	updated, err := ctl.UserUC.SetDisabled(ctx, actorFromContext(c), c.Param("username"), false)
+	respondUser(c, updated, err, "failed to enable user")
+}
+
+// respondUser writes the outcome of an admin change to a user
+func respondUser(c *gin.Context, u Domain.User, err error, fallback string) {
+	if err != nil {
+		writeTaskError(c, err, fallback)
+		return
+	}
+	if u.Username == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"username": u.Username, "role": u.Role, "disabled": u.Disabled})
+}
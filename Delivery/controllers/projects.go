@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"task_manager1/Domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+func toProjectResponse(p Domain.Project) Domain.ProjectResponse {
+	resp := Domain.ProjectResponse{
+		ID:          p.ID.Hex(),
+		Name:        p.Name,
+		Description: p.Description,
+		Personal:    !p.PersonalOf.IsZero(),
+		Members:     make([]Domain.ProjectMemberResponse, 0, len(p.Members)),
+		CreatedBy:   p.CreatedBy,
+	}
+	for _, m := range p.Members {
+		resp.Members = append(resp.Members, Domain.ProjectMemberResponse{UserID: m.UserID.Hex(), Username: m.Username, Role: m.Role})
+	}
+	if !p.CreatedAt.IsZero() {
+		resp.CreatedAt = p.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	if !p.UpdatedAt.IsZero() {
+		resp.UpdatedAt = p.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+	return resp
+}
+
+// GetProjects lists the caller's projects (every project for admins)
+func (ctl *Controller) GetProjects(c *gin.Context) {
+	ctx := requestContext(c)
+	projects, err := ctl.ProjectUC.List(ctx, actorFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch projects"})
+		return
+	}
+	resp := make([]Domain.ProjectResponse, 0, len(projects))
+	for _, p := range projects {
+		resp = append(resp, toProjectResponse(p))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetProject returns a project the caller belongs to
+func (ctl *Controller) GetProject(c *gin.Context) {
+	ctx := requestContext(c)
+	p, err := ctl.ProjectUC.GetByID(ctx, actorFromContext(c), c.Param("id"))
+	respondProject(c, http.StatusOK, p, err)
+}
+
+// CreateProject creates a project owned by the caller
+func (ctl *Controller) CreateProject(c *gin.Context) {
+	var input Domain.ProjectInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+	ctx := requestContext(c)
+	p, err := ctl.ProjectUC.Create(ctx, actorFromContext(c), input)
+	respondProject(c, http.StatusCreated, p, err)
+}
+
+// UpdateProject renames a project or changes its description (project owners)
+func (ctl *Controller) UpdateProject(c *gin.Context) {
+	var input Domain.ProjectInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+	ctx := requestContext(c)
+	p, err := ctl.ProjectUC.Update(ctx, actorFromContext(c), c.Param("id"), input)
+	respondProject(c, http.StatusOK, p, err)
+}
+
+// DeleteProject removes a project without tasks (project owners)
+func (ctl *Controller) DeleteProject(c *gin.Context) {
+	ctx := requestContext(c)
+	ok, err := ctl.ProjectUC.Delete(ctx, actorFromContext(c), c.Param("id"))
+	if err != nil {
+		writeTaskError(c, err, "failed to delete project")
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "project deleted"})
+}
+
+// SetProjectMember adds a user to a project or changes their role (project owners)
+func (ctl *Controller) SetProjectMember(c *gin.Context) {
+	var input Domain.ProjectMemberInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+	ctx := requestContext(c)
+	p, err := ctl.ProjectUC.SetMember(ctx, actorFromContext(c), c.Param("id"), c.Param("username"), input.Role)
+	respondProject(c, http.StatusOK, p, err)
+}
+
+// RemoveProjectMember takes a user out of a project; members may remove themselves
+func (ctl *Controller) RemoveProjectMember(c *gin.Context) {
+	ctx := requestContext(c)
+	p, err := ctl.ProjectUC.RemoveMember(ctx, actorFromContext(c), c.Param("id"), c.Param("username"))
+	respondProject(c, http.StatusOK, p, err)
+}
+
+func respondProject(c *gin.Context, status int, p Domain.Project, err error) {
+	if err != nil {
+		writeTaskError(c, err, "failed to save project")
+		return
+	}
+	if p.ID.IsZero() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+	c.JSON(status, toProjectResponse(p))
+}
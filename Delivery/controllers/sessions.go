@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"task_manager1/Domain"
+	"task_manager1/Infrastructure/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// startSession answers a successful login with an access token and the
+// first refresh token of a new family
+func (ctl *Controller) startSession(c *gin.Context, ctx context.Context, status int, u Domain.User) {
+	refresh, err := ctl.UserUC.StartSession(ctx, u)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+	ctl.writeTokens(c, status, u, refresh)
+}
+
+// writeTokens signs an access token for u and writes it with refresh
+func (ctl *Controller) writeTokens(c *gin.Context, status int, u Domain.User, refresh string) {
+	token, err := ctl.JWT.GenerateToken(u.ID.Hex(), u.Username, u.Role, u.TenantID.Hex(), u.TokenVersion)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+	c.JSON(status, gin.H{
+		"id":              u.ID.Hex(),
+		"username":        u.Username,
+		"role":            u.Role,
+		"organization_id": u.TenantID.Hex(),
+		"token":           token,
+		"token_type":      "Bearer",
+		"expires_in":      int(ctl.JWT.TTL().Seconds()),
+		"refresh_token":   refresh,
+	})
+}
+
+// RefreshToken exchanges a refresh token for a new access and refresh token
+func (ctl *Controller) RefreshToken(c *gin.Context) {
+	var body struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token required"})
+		return
+	}
+	ctx := context.Background()
+	u, refresh, err := ctl.UserUC.Refresh(ctx, body.RefreshToken)
+	switch {
+	case errors.Is(err, Domain.ErrInvalidRefreshToken), errors.Is(err, Domain.ErrRefreshTokenReused):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refresh token"})
+		return
+	}
+	ctl.writeTokens(c, http.StatusOK, u, refresh)
+}
+
+// Logout revokes the caller's access token and, when given, its refresh token
+func (ctl *Controller) Logout(c *gin.Context) {
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+			return
+		}
+	}
+	ctx := requestContext(c)
+	p, _ := auth.PrincipalFrom(c)
+	if err := ctl.UserUC.EndSession(ctx, p.UserID, body.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log out"})
+		return
+	}
+	if p.TokenID != "" && ctl.Revoked != nil {
+		if err := ctl.Revoked.Revoke(ctx, p.TokenID, p.ExpiresAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log out"})
+			return
+		}
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeSessions signs a user out everywhere (admin)
+func (ctl *Controller) RevokeSessions(c *gin.Context) {
+	ctx := requestContext(c)
+	u, err := ctl.UserUC.RevokeSessions(ctx, c.Param("username"))
+	if err != nil {
+		writeTaskError(c, err, "failed to revoke sessions")
+		return
+	}
+	if ctl.Revoked != nil {
+		if err := ctl.Revoked.RevokeUser(ctx, u.ID.Hex(), time.Now()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke sessions"})
+			return
+		}
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetJWKS publishes the public keys access tokens are verified with
+func (ctl *Controller) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, ctl.JWT.JWKS())
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"net/http"
+
+	"task_manager1/Domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTaskChildren lists the subtasks of a task. It accepts the GET /tasks
+// query parameters.
+func (ctl *Controller) GetTaskChildren(c *gin.Context) {
+	actor := actorFromContext(c)
+	f, ok := ctl.taskFilterFromQuery(c, actor)
+	if !ok {
+		return
+	}
+	ctx := requestContext(c)
+	page, err := ctl.TaskUC.Children(ctx, actor, c.Param("id"), f)
+	if err == nil && page.Tasks == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+	ctl.writeTaskPage(c, f, page, err)
+}
+
+// AddChecklistItem appends an item to a task's checklist (owner or admin)
+func (ctl *Controller) AddChecklistItem(c *gin.Context) {
+	var body struct {
+		Text string `json:"text" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "text required"})
+		return
+	}
+	ifVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	ctx := requestContext(c)
+	updated, err := ctl.TaskUC.AddChecklistItem(ctx, actorFromContext(c), c.Param("id"), body.Text, ifVersion)
+	ctl.respondChecklist(c, http.StatusCreated, updated, err)
+}
+
+// UpdateChecklistItem edits an item's text or toggles its done flag
+func (ctl *Controller) UpdateChecklistItem(c *gin.Context) {
+	var body struct {
+		Text *string `json:"text"`
+		Done *bool   `json:"done"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || (body.Text == nil && body.Done == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "text or done required"})
+		return
+	}
+	ifVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	ctx := requestContext(c)
+	updated, err := ctl.TaskUC.UpdateChecklistItem(ctx, actorFromContext(c), c.Param("id"), c.Param("item"), body.Text, body.Done, ifVersion)
+	ctl.respondChecklist(c, http.StatusOK, updated, err)
+}
+
+// ReorderChecklist sets the order of a task's checklist items
+func (ctl *Controller) ReorderChecklist(c *gin.Context) {
+	var body struct {
+		Order []string `json:"order" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order required"})
+		return
+	}
+	ifVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	ctx := requestContext(c)
+	updated, err := ctl.TaskUC.ReorderChecklist(ctx, actorFromContext(c), c.Param("id"), body.Order, ifVersion)
+	ctl.respondChecklist(c, http.StatusOK, updated, err)
+}
+
+// RemoveChecklistItem deletes an item from a task's checklist
+func (ctl *Controller) RemoveChecklistItem(c *gin.Context) {
+	ifVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	ctx := requestContext(c)
+	updated, err := ctl.TaskUC.RemoveChecklistItem(ctx, actorFromContext(c), c.Param("id"), c.Param("item"), ifVersion)
+	ctl.respondChecklist(c, http.StatusOK, updated, err)
+}
+
+func (ctl *Controller) respondChecklist(c *gin.Context, code int, updated Domain.Task, err error) {
+	if err != nil {
+		writeTaskError(c, err, "failed to update checklist")
+		return
+	}
+	if updated.ID.IsZero() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+	c.Header("ETag", etag(updated.Version))
+	c.JSON(code, ctl.taskResponse(updated))
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"net/http"
+
+	"task_manager1/Domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+func toTagResponse(t Domain.Tag) Domain.TagResponse {
+	return Domain.TagResponse{ID: t.ID.Hex(), Name: t.Name, Color: t.Color}
+}
+
+// GetTags lists the tag catalogue
+func (ctl *Controller) GetTags(c *gin.Context) {
+	ctx := requestContext(c)
+	tags, err := ctl.TaskUC.Tags(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch tags"})
+		return
+	}
+	resp := make([]Domain.TagResponse, 0, len(tags))
+	for _, t := range tags {
+		resp = append(resp, toTagResponse(t))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetTag returns one catalogue entry
+func (ctl *Controller) GetTag(c *gin.Context) {
+	ctx := requestContext(c)
+	tag, err := ctl.TaskUC.Tag(ctx, c.Param("id"))
+	ctl.respondTag(c, http.StatusOK, tag, err)
+}
+
+// CreateTag adds a tag to the catalogue
+func (ctl *Controller) CreateTag(c *gin.Context) {
+	var input Domain.TagInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+	ctx := requestContext(c)
+	tag, err := ctl.TaskUC.CreateTag(ctx, actorFromContext(c), input)
+	ctl.respondTag(c, http.StatusCreated, tag, err)
+}
+
+// UpdateTag renames or recolors a tag, renaming it on every task (admin)
+func (ctl *Controller) UpdateTag(c *gin.Context) {
+	var input Domain.TagInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+	ctx := requestContext(c)
+	tag, err := ctl.TaskUC.UpdateTag(ctx, actorFromContext(c), c.Param("id"), input)
+	ctl.respondTag(c, http.StatusOK, tag, err)
+}
+
+// DeleteTag removes a tag from the catalogue and from every task (admin)
+func (ctl *Controller) DeleteTag(c *gin.Context) {
+	ctx := requestContext(c)
+	ok, err := ctl.TaskUC.DeleteTag(ctx, actorFromContext(c), c.Param("id"))
+	if err != nil {
+		writeTaskError(c, err, "failed to delete tag")
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "tag deleted"})
+}
+
+func (ctl *Controller) respondTag(c *gin.Context, status int, tag Domain.Tag, err error) {
+	if err != nil {
+		writeTaskError(c, err, "failed to save tag")
+		return
+	}
+	if tag.ID.IsZero() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
+		return
+	}
+	c.JSON(status, toTagResponse(tag))
+}
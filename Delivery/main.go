@@ -5,12 +5,17 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
+	_ "time/tzdata"
 
 	"task_manager1/Delivery/controllers"
 	"task_manager1/Delivery/routers"
+	"task_manager1/Domain"
 	"task_manager1/Infrastructure/auth"
 	"task_manager1/Infrastructure/security"
+	"task_manager1/Infrastructure/storage"
 	"task_manager1/Repositories"
 	"task_manager1/Usecases"
 
@@ -33,6 +38,121 @@ func NewMongoClient(ctx context.Context, uri string) (*mongo.Client, error) {
 	return client, nil
 }
 
+// durationEnv reads a Go duration such as "720h" from the environment.
+func durationEnv(name string, def time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a positive duration such as 720h", name, value)
+	}
+	return d, nil
+}
+
+// leewayEnv reads JWT_LEEWAY, the clock skew tolerated on token times. Unlike
+// other durations it may be zero.
+func leewayEnv() (time.Duration, error) {
+	value := os.Getenv("JWT_LEEWAY")
+	if value == "" {
+		return auth.DefaultLeeway, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid JWT_LEEWAY %q: must be a duration such as 30s", value)
+	}
+	return d, nil
+}
+
+// newBlobStore picks where attachment contents are kept: a GridFS bucket
+// (the default) or, with ATTACHMENT_STORE=local, a directory.
+func newBlobStore(db *mongo.Database) (storage.BlobStore, error) {
+	switch store := os.Getenv("ATTACHMENT_STORE"); store {
+	case "", "gridfs":
+		bucket := os.Getenv("ATTACHMENT_BUCKET")
+		if bucket == "" {
+			bucket = "attachments"
+		}
+		return storage.NewGridFSBlobStore(db, bucket)
+	case "local":
+		dir := os.Getenv("ATTACHMENT_DIR")
+		if dir == "" {
+			dir = "attachments"
+		}
+		return storage.NewLocalBlobStore(dir)
+	default:
+		return nil, fmt.Errorf("invalid ATTACHMENT_STORE %q: must be gridfs or local", store)
+	}
+}
+
+// newJWTService signs tokens with the PEM private key in JWT_PRIVATE_KEY_FILE
+// (kid JWT_KEY_ID) when set, otherwise with JWT_SECRET. JWT_PUBLIC_KEY_FILES
+// lists further verification keys as kid=path pairs separated by commas, so
+// tokens signed by a retired key stay valid while keys rotate.
+func newJWTService() (*auth.JWTService, error) {
+	keyFile := os.Getenv("JWT_PRIVATE_KEY_FILE")
+	if keyFile == "" {
+		return auth.NewJWTService(), nil
+	}
+	signing, err := auth.LoadSigningKey(os.Getenv("JWT_KEY_ID"), keyFile)
+	if err != nil {
+		return nil, err
+	}
+	var verify []auth.VerificationKey
+	for _, entry := range strings.Split(os.Getenv("JWT_PUBLIC_KEY_FILES"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		kid, path, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid JWT_PUBLIC_KEY_FILES entry %q: must be kid=path", entry)
+		}
+		key, err := auth.LoadVerificationKey(kid, path)
+		if err != nil {
+			return nil, err
+		}
+		verify = append(verify, key)
+	}
+	return auth.NewAsymmetricJWTService(signing, verify...)
+}
+
+// newRevocationStore picks where revoked access tokens are remembered: a
+// TTL-indexed collection (the default) or, with REVOCATION_STORE=memory,
+// process memory, which suits a single instance only.
+func newRevocationStore(coll *mongo.Collection, ttl time.Duration) (auth.RevocationStore, error) {
+	switch store := os.Getenv("REVOCATION_STORE"); store {
+	case "", "mongo":
+		return auth.NewMongoRevocationStore(coll, ttl), nil
+	case "memory":
+		return auth.NewMemoryRevocationStore(ttl), nil
+	default:
+		return nil, fmt.Errorf("invalid REVOCATION_STORE %q: must be mongo or memory", store)
+	}
+}
+
+// attachmentPolicy applies ATTACHMENT_MAX_BYTES and ATTACHMENT_TYPES (a
+// comma separated list of media types) to the default upload limits.
+func attachmentPolicy() (Domain.AttachmentPolicy, error) {
+	p := Domain.DefaultAttachmentPolicy()
+	if value := os.Getenv("ATTACHMENT_MAX_BYTES"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n <= 0 {
+			return p, fmt.Errorf("invalid ATTACHMENT_MAX_BYTES %q: must be a positive number of bytes", value)
+		}
+		p.MaxSize = n
+	}
+	if value := os.Getenv("ATTACHMENT_TYPES"); value != "" {
+		p.AllowedTypes = nil
+		for _, t := range strings.Split(value, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				p.AllowedTypes = append(p.AllowedTypes, t)
+			}
+		}
+	}
+	return p, nil
+}
+
 func main() {
 	_ = godotenv.Load()
 
@@ -40,14 +160,46 @@ func main() {
 	dbName := os.Getenv("MONGODB_DATABASE")
 	taskColl := os.Getenv("TASKS_COLLECTION")
 	userColl := os.Getenv("USERS_COLLECTION")
+	historyColl := os.Getenv("HISTORY_COLLECTION")
+	if historyColl == "" {
+		historyColl = "task_history"
+	}
+	tagColl := os.Getenv("TAGS_COLLECTION")
+	if tagColl == "" {
+		tagColl = "tags"
+	}
+	commentColl := os.Getenv("COMMENTS_COLLECTION")
+	if commentColl == "" {
+		commentColl = "comments"
+	}
+	projectColl := os.Getenv("PROJECTS_COLLECTION")
+	if projectColl == "" {
+		projectColl = "projects"
+	}
+	orgColl := os.Getenv("ORGANIZATIONS_COLLECTION")
+	if orgColl == "" {
+		orgColl = "organizations"
+	}
+	refreshColl := os.Getenv("REFRESH_TOKENS_COLLECTION")
+	if refreshColl == "" {
+		refreshColl = "refresh_tokens"
+	}
+	revokedColl := os.Getenv("REVOKED_TOKENS_COLLECTION")
+	if revokedColl == "" {
+		revokedColl = "revoked_tokens"
+	}
+	defaultOrg := os.Getenv("DEFAULT_ORGANIZATION")
+	if defaultOrg == "" {
+		defaultOrg = "Default"
+	}
 	jwtSecret := os.Getenv("JWT_SECRET")
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	if uri == "" || dbName == "" || taskColl == "" || userColl == "" || jwtSecret == "" {
-		log.Fatal("MONGODB_URI, MONGODB_DATABASE, TASKS_COLLECTION, USERS_COLLECTION and JWT_SECRET must be set")
+	if uri == "" || dbName == "" || taskColl == "" || userColl == "" || (jwtSecret == "" && os.Getenv("JWT_PRIVATE_KEY_FILE") == "") {
+		log.Fatal("MONGODB_URI, MONGODB_DATABASE, TASKS_COLLECTION, USERS_COLLECTION and JWT_SECRET or JWT_PRIVATE_KEY_FILE must be set")
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
@@ -62,21 +214,126 @@ func main() {
 	taskCollection := db.Collection(taskColl)
 	userCollection := db.Collection(userColl)
 
+	// Convert legacy string due dates before serving
+	migrateCtx, cancelMigrate := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancelMigrate()
+	migrated, unparsed, err := Repositories.MigrateDueDates(migrateCtx, taskCollection, userCollection)
+	if err != nil {
+		log.Fatalf("due date migration error: %v", err)
+	}
+	if migrated > 0 || unparsed > 0 {
+		log.Printf("due date migration: %d converted, %d moved to due_date_legacy", migrated, unparsed)
+	}
+	orgRepo := Repositories.NewMongoOrganizationRepository(db.Collection(orgColl))
+	if n, err := Repositories.BackfillTenants(migrateCtx, orgRepo, defaultOrg, userCollection, taskCollection,
+		db.Collection(projectColl), db.Collection(tagColl), db.Collection(historyColl), db.Collection(commentColl)); err != nil {
+		log.Fatalf("organization backfill error: %v", err)
+	} else if n > 0 {
+		log.Printf("organization backfill: %d documents moved into %q", n, defaultOrg)
+	}
+	if n, err := Repositories.BackfillTaskTimestamps(migrateCtx, taskCollection); err != nil {
+		log.Fatalf("timestamp backfill error: %v", err)
+	} else if n > 0 {
+		log.Printf("timestamp backfill: %d tasks updated", n)
+	}
+	if n, err := Repositories.BackfillTaskPriorities(migrateCtx, taskCollection); err != nil {
+		log.Fatalf("priority backfill error: %v", err)
+	} else if n > 0 {
+		log.Printf("priority backfill: %d tasks updated", n)
+	}
+
 	// Wire Repositories
 	userRepo := Repositories.NewMongoUserRepository(userCollection)
 	taskRepo := Repositories.NewMongoTaskRepository(taskCollection)
+	historyRepo := Repositories.NewMongoHistoryRepository(db.Collection(historyColl))
+	tagRepo := Repositories.NewMongoTagRepository(db.Collection(tagColl))
+	commentRepo := Repositories.NewMongoCommentRepository(db.Collection(commentColl))
+	projectRepo := Repositories.NewMongoProjectRepository(db.Collection(projectColl))
+	refreshRepo := Repositories.NewMongoRefreshTokenRepository(db.Collection(refreshColl))
+	if n, err := Repositories.BackfillTaskProjects(migrateCtx, taskCollection, userCollection, projectRepo); err != nil {
+		log.Fatalf("project backfill error: %v", err)
+	} else if n > 0 {
+		log.Printf("project backfill: %d tasks moved into personal projects", n)
+	}
+	blobs, err := newBlobStore(db)
+	if err != nil {
+		log.Fatalf("attachment store error: %v", err)
+	}
 
 	// Infrastructure services
 	pwSvc := security.NewPasswordService()
-	jwtSvc := auth.NewJWTService()
-	authMw := auth.NewAuthMiddleware()
+	jwtSvc, err := newJWTService()
+	if err != nil {
+		log.Fatalf("jwt key error: %v", err)
+	}
+	accessTTL, err := durationEnv("ACCESS_TOKEN_TTL", auth.DefaultAccessTokenTTL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	leeway, err := leewayEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	jwtSvc.SetTokenTiming(accessTTL, leeway)
+	revoked, err := newRevocationStore(db.Collection(revokedColl), jwtSvc.TTL())
+	if err != nil {
+		log.Fatal(err)
+	}
+	authMw := auth.NewAuthMiddleware(jwtSvc, revoked, userRepo)
+	userCacheTTL, err := durationEnv("USER_CACHE_TTL", auth.DefaultUserCacheTTL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	authMw.SetUserCacheTTL(userCacheTTL)
 
 	// Usecases
-	userUC := Usecases.NewUserUsecase(userRepo, pwSvc)
-	taskUC := Usecases.NewTaskUsecase(taskRepo)
+	userUC := Usecases.NewUserUsecase(userRepo, orgRepo, refreshRepo, pwSvc)
+	refreshTTL, err := durationEnv("REFRESH_TOKEN_TTL", Usecases.DefaultRefreshTTL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	userUC.SetRefreshTTL(refreshTTL)
+	taskUC := Usecases.NewTaskUsecase(taskRepo, userRepo, historyRepo, tagRepo, commentRepo, blobs, projectRepo)
+	projectUC := Usecases.NewProjectUsecase(projectRepo, taskRepo, userRepo)
+	if spec := os.Getenv("TASK_WORKFLOW"); spec != "" {
+		wf, err := Domain.ParseWorkflow(spec)
+		if err != nil {
+			log.Fatalf("invalid TASK_WORKFLOW: %v", err)
+		}
+		taskUC.SetWorkflow(wf)
+	} else if os.Getenv("TASK_WORKFLOW_ALLOW_REOPEN") == "true" {
+		taskUC.SetWorkflow(Domain.DefaultWorkflow(true))
+	}
+	policy, err := attachmentPolicy()
+	if err != nil {
+		log.Fatal(err)
+	}
+	taskUC.SetAttachmentPolicy(policy)
+
+	// Purge trashed tasks in the background
+	retention, err := durationEnv("TASK_TRASH_RETENTION", 30*24*time.Hour)
+	if err != nil {
+		log.Fatal(err)
+	}
+	purgeEvery, err := durationEnv("TASK_PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go taskUC.RunTrashPurger(context.Background(), purgeEvery, retention)
+
+	// Create upcoming occurrences of recurring tasks ahead of time
+	horizon, err := durationEnv("TASK_RECURRENCE_HORIZON", 7*24*time.Hour)
+	if err != nil {
+		log.Fatal(err)
+	}
+	materializeEvery, err := durationEnv("TASK_RECURRENCE_INTERVAL", 15*time.Minute)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go taskUC.RunRecurrenceMaterializer(context.Background(), materializeEvery, horizon)
 
 	// controller
-	ctl := controllers.NewController(userUC, taskUC, jwtSvc)
+	ctl := controllers.NewController(userUC, taskUC, projectUC, jwtSvc, revoked)
 
 	// router
 	r := routers.SetupRouter(ctl, authMw)
@@ -13,23 +13,69 @@ func SetupRouter(ctl *controllers.Controller, authMw *auth.AuthMiddleware) *gin.
 	// Public routes
 	r.POST("/register", ctl.Register)
 	r.POST("/login", ctl.Login)
+	r.POST("/token/refresh", ctl.RefreshToken)
+	r.GET("/.well-known/jwks.json", ctl.GetJWKS)
 
 	// Authenticated routes
 	authGroup := r.Group("/")
 	authGroup.Use(authMw.Handle())
 	{
 		authGroup.GET("/tasks", ctl.GetTasks)
+		authGroup.GET("/tasks/overdue", ctl.GetOverdueTasks)
 		authGroup.GET("/tasks/:id", ctl.GetTaskByID)
+		authGroup.POST("/tasks", ctl.CreateTask)
+		authGroup.PUT("/tasks/:id", ctl.UpdateTask)
+		authGroup.PATCH("/tasks/:id", ctl.PatchTask)
+		authGroup.DELETE("/tasks/:id", ctl.DeleteTask)
+		authGroup.GET("/tasks/:id/history", ctl.GetTaskHistory)
+		authGroup.POST("/tasks/:id/restore", ctl.RestoreTask)
+		authGroup.GET("/tasks/:id/children", ctl.GetTaskChildren)
+		authGroup.POST("/tasks/:id/checklist", ctl.AddChecklistItem)
+		authGroup.PUT("/tasks/:id/checklist/order", ctl.ReorderChecklist)
+		authGroup.PATCH("/tasks/:id/checklist/:item", ctl.UpdateChecklistItem)
+		authGroup.DELETE("/tasks/:id/checklist/:item", ctl.RemoveChecklistItem)
+		authGroup.POST("/tasks/:id/dependencies", ctl.AddDependency)
+		authGroup.DELETE("/tasks/:id/dependencies/:blocker", ctl.RemoveDependency)
+		authGroup.GET("/tasks/:id/graph", ctl.GetTaskGraph)
+		authGroup.GET("/tasks/:id/comments", ctl.GetComments)
+		authGroup.POST("/tasks/:id/comments", ctl.AddComment)
+		authGroup.PUT("/tasks/:id/comments/:comment", ctl.EditComment)
+		authGroup.DELETE("/tasks/:id/comments/:comment", ctl.DeleteComment)
+		authGroup.POST("/tasks/:id/attachments", ctl.UploadAttachment)
+		authGroup.GET("/tasks/:id/attachments/:attachment", ctl.DownloadAttachment)
+		authGroup.DELETE("/tasks/:id/attachments/:attachment", ctl.DeleteAttachment)
+		authGroup.GET("/trash", ctl.GetTrash)
+		authGroup.GET("/projects", ctl.GetProjects)
+		authGroup.POST("/projects", ctl.CreateProject)
+		authGroup.GET("/projects/:id", ctl.GetProject)
+		authGroup.PUT("/projects/:id", ctl.UpdateProject)
+		authGroup.DELETE("/projects/:id", ctl.DeleteProject)
+		authGroup.PUT("/projects/:id/members/:username", ctl.SetProjectMember)
+		authGroup.DELETE("/projects/:id/members/:username", ctl.RemoveProjectMember)
+		authGroup.GET("/tags", ctl.GetTags)
+		authGroup.GET("/tags/:id", ctl.GetTag)
+		authGroup.POST("/tags", ctl.CreateTag)
+		authGroup.POST("/tasks/:id/assignees", ctl.AssignTask)
+		authGroup.DELETE("/tasks/:id/assignees/:username", ctl.UnassignTask)
+		authGroup.PUT("/me/timezone", ctl.SetTimezone)
+		authGroup.GET("/workflow", ctl.GetWorkflow)
+		authGroup.GET("/organization", ctl.GetOrganization)
+		authGroup.POST("/logout", ctl.Logout)
 	}
 
 	// Admin routes
 	admin := r.Group("/")
 	admin.Use(authMw.Handle(), authMw.RequireAdmin())
 	{
-		admin.POST("/tasks", ctl.CreateTask)
-		admin.PUT("/tasks/:id", ctl.UpdateTask)
-		admin.DELETE("/tasks/:id", ctl.DeleteTask)
+		admin.POST("/users", ctl.CreateUser)
+		admin.DELETE("/users/:username/sessions", ctl.RevokeSessions)
 		admin.POST("/promote/:username", ctl.Promote)
+		admin.POST("/demote/:username", ctl.Demote)
+		admin.POST("/users/:username/disable", ctl.DisableUser)
+		admin.POST("/users/:username/enable", ctl.EnableUser)
+		admin.POST("/tasks/priority", ctl.ReprioritizeTasks)
+		admin.PUT("/tags/:id", ctl.UpdateTag)
+		admin.DELETE("/tags/:id", ctl.DeleteTag)
 	}
 
 	return r
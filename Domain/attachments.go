@@ -0,0 +1,83 @@
+package Domain
+
+import (
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Attachment describes a file uploaded to a task. The contents live in the
+// blob store under the attachment's ID.
+type Attachment struct {
+	ID          primitive.ObjectID `bson:"id"`
+	Name        string             `bson:"name"`
+	Size        int64              `bson:"size"`
+	ContentType string             `bson:"content_type"`
+	SHA256      string             `bson:"sha256"`
+	UploadedBy  string             `bson:"uploaded_by"`
+	UploadedAt  time.Time          `bson:"uploaded_at"`
+}
+
+// AttachmentResponse for API (ID as hex)
+type AttachmentResponse struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+	SHA256      string `json:"sha256"`
+	UploadedBy  string `json:"uploaded_by"`
+	UploadedAt  string `json:"uploaded_at"`
+}
+
+// AttachmentPolicy limits what can be uploaded. AllowedTypes holds media
+// types such as "application/pdf" or wildcards such as "image/*".
+type AttachmentPolicy struct {
+	MaxSize      int64
+	MaxPerTask   int
+	AllowedTypes []string
+}
+
+// DefaultAttachmentPolicy allows common documents and images up to 10 MiB.
+func DefaultAttachmentPolicy() AttachmentPolicy {
+	return AttachmentPolicy{
+		MaxSize:    10 << 20,
+		MaxPerTask: 50,
+		AllowedTypes: []string{
+			"image/*",
+			"text/plain",
+			"text/csv",
+			"application/pdf",
+			"application/zip",
+			"application/json",
+			"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+			"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+		},
+	}
+}
+
+// Allows reports whether the policy accepts the media type.
+func (p AttachmentPolicy) Allows(mediaType string) bool {
+	mediaType = strings.ToLower(mediaType)
+	for _, allowed := range p.AllowedTypes {
+		allowed = strings.ToLower(allowed)
+		if allowed == mediaType || allowed == "*/*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Attachment returns the attachment with the given hex ID, if the task has it.
+func (t Task) Attachment(hexID string) (Attachment, bool) {
+	for _, a := range t.Attachments {
+		if a.ID.Hex() == hexID {
+			return a, true
+		}
+	}
+	return Attachment{}, false
+}
@@ -0,0 +1,69 @@
+package Domain
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// mentionPattern matches @username where the @ does not follow a word
+// character, so e-mail addresses are not taken for mentions.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@(\w[\w.-]*)`)
+
+// Comment is one message in the discussion thread of a task.
+type Comment struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	TenantID  primitive.ObjectID `bson:"tenant_id,omitempty"`
+	TaskID    primitive.ObjectID `bson:"task_id"`
+	AuthorID  primitive.ObjectID `bson:"author_id"`
+	Author    string             `bson:"author"`
+	Body      string             `bson:"body"`
+	Mentions  []string           `bson:"mentions,omitempty"`
+	CreatedAt time.Time          `bson:"created_at"`
+	EditedAt  *time.Time         `bson:"edited_at,omitempty"` // set once the author changes the body
+}
+
+// CommentInput is the client payload for posting or editing a comment.
+type CommentInput struct {
+	Body string `json:"body"`
+}
+
+// CommentResponse for API (IDs as hex)
+type CommentResponse struct {
+	ID        string   `json:"id"`
+	TaskID    string   `json:"task_id"`
+	Author    string   `json:"author"`
+	Body      string   `json:"body"`
+	Mentions  []string `json:"mentions"`
+	CreatedAt string   `json:"created_at"`
+	EditedAt  string   `json:"edited_at,omitempty"`
+}
+
+// ParseMentions returns the distinct usernames mentioned as @username in
+// body, in order of first appearance.
+func ParseMentions(body string) []string {
+	mentions := []string{}
+	seen := map[string]bool{}
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		// a mention at the end of a sentence keeps its full stop otherwise
+		name := strings.TrimRight(m[1], ".-")
+		if !seen[name] {
+			seen[name] = true
+			mentions = append(mentions, name)
+		}
+	}
+	return mentions
+}
+
+// CanEdit reports whether the actor wrote the comment. Only authors may
+// change what they wrote.
+func (c Comment) CanEdit(a Actor) bool {
+	return !c.AuthorID.IsZero() && c.AuthorID == a.UserID
+}
+
+// CanDelete reports whether the actor may remove the comment: its author or an admin.
+func (c Comment) CanDelete(a Actor) bool {
+	return c.CanEdit(a) || a.IsAdmin()
+}
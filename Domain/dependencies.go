@@ -0,0 +1,41 @@
+package Domain
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// DependencyEdge says that From blocks To.
+type DependencyEdge struct {
+	From primitive.ObjectID
+	To   primitive.ObjectID
+}
+
+// TaskGraph is the transitive dependency graph around Root: every task it
+// waits on and every task waiting on it. Truncated is set when the graph was
+// cut off at the size limit.
+type TaskGraph struct {
+	Root      primitive.ObjectID
+	Nodes     []Task
+	Edges     []DependencyEdge
+	Truncated bool
+}
+
+// TaskGraphNode is a task in the API representation of a TaskGraph
+type TaskGraphNode struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Status  string `json:"status,omitempty"`
+	Blocked bool   `json:"blocked"`
+}
+
+// TaskGraphEdge is a dependency in the API representation of a TaskGraph
+type TaskGraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// TaskGraphResponse is returned by GET /tasks/:id/graph
+type TaskGraphResponse struct {
+	Root      string          `json:"root"`
+	Nodes     []TaskGraphNode `json:"nodes"`
+	Edges     []TaskGraphEdge `json:"edges"`
+	Truncated bool            `json:"truncated"`
+}
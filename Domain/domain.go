@@ -1,29 +1,289 @@
 package Domain
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 // Task entity
 type Task struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"-"`
-	Title       string             `bson:"title" json:"title"`
-	Description string             `bson:"description,omitempty" json:"description,omitempty"`
-	DueDate     string             `bson:"due_date,omitempty" json:"due_date,omitempty"`
-	Status      string             `bson:"status,omitempty" json:"status,omitempty"`
+	ID           primitive.ObjectID   `bson:"_id,omitempty" json:"-"`
+	TenantID     primitive.ObjectID   `bson:"tenant_id,omitempty" json:"-"`
+	Title        string               `bson:"title" json:"title"`
+	Description  string               `bson:"description,omitempty" json:"description,omitempty"`
+	DueDate      *time.Time           `bson:"due_date,omitempty" json:"due_date,omitempty"`
+	Status       string               `bson:"status,omitempty" json:"status,omitempty"`
+	Priority     string               `bson:"priority,omitempty" json:"-"`
+	PriorityRank int                  `bson:"priority_rank,omitempty" json:"-"`
+	CreatorID    primitive.ObjectID   `bson:"creator_id,omitempty" json:"-"`
+	OwnerID      primitive.ObjectID   `bson:"owner_id,omitempty" json:"-"`
+	ProjectID    primitive.ObjectID   `bson:"project_id,omitempty" json:"-"`
+	SharedWith   []primitive.ObjectID `bson:"shared_with,omitempty" json:"shared_with,omitempty"`
+	Assignees    []string             `bson:"assignees,omitempty" json:"-"`
+	Version      int64                `bson:"version" json:"-"`
+	CreatedAt    time.Time            `bson:"created_at" json:"-"`
+	UpdatedAt    time.Time            `bson:"updated_at" json:"-"`
+	CreatedBy    string               `bson:"created_by,omitempty" json:"-"`
+	UpdatedBy    string               `bson:"updated_by,omitempty" json:"-"`
+	DeletedAt    *time.Time           `bson:"deleted_at,omitempty" json:"-"`
+	DeletedBy    string               `bson:"deleted_by,omitempty" json:"-"`
+	ParentID     primitive.ObjectID   `bson:"parent_id,omitempty" json:"-"`
+	Checklist    []ChecklistItem      `bson:"checklist,omitempty" json:"-"`
+	BlockedBy    []primitive.ObjectID `bson:"blocked_by,omitempty" json:"-"`
+	Recurrence   *TaskRecurrence      `bson:"recurrence,omitempty" json:"-"`
+	Tags         []string             `bson:"tags,omitempty" json:"-"`
+	Attachments  []Attachment         `bson:"attachments,omitempty" json:"-"`
+	// Subtasks and Blocked are computed when the task is read and never stored
+	Subtasks SubtaskCounts `bson:"-" json:"-"`
+	Blocked  bool          `bson:"-" json:"-"`
+}
+
+// ChecklistItem is one step embedded in a task
+type ChecklistItem struct {
+	ID   primitive.ObjectID `bson:"id" json:"id"`
+	Text string             `bson:"text" json:"text"`
+	Done bool               `bson:"done" json:"done"`
+}
+
+// SubtaskCounts summarises the live (not trashed) children of a task
+type SubtaskCounts struct {
+	Total int
+	Done  int
+}
+
+// TaskInput is the client payload for creating or updating a task.
+// DueDate accepts RFC 3339 or a date-only YYYY-MM-DD value, which is read
+// in the author's time zone. Priority defaults to medium and ProjectID to
+// the author's personal project.
+type TaskInput struct {
+	Title       string               `json:"title"`
+	Description string               `json:"description,omitempty"`
+	DueDate     string               `json:"due_date,omitempty"`
+	Status      string               `json:"status,omitempty"`
+	Priority    string               `json:"priority,omitempty"`
+	SharedWith  []primitive.ObjectID `json:"shared_with,omitempty"`
+	ParentID    primitive.ObjectID   `json:"parent_id,omitempty"`
+	ProjectID   primitive.ObjectID   `json:"project_id,omitempty"`
+	// Recurrence is an RFC 5545 RRULE; recurring tasks need a due date
+	Recurrence string `json:"recurrence,omitempty"`
+	// Tags must exist in the tag catalogue
+	Tags []string `json:"tags,omitempty"`
 }
 
 // TaskResponse for API (ID as hex)
 type TaskResponse struct {
-	ID          string `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"description,omitempty"`
-	DueDate     string `json:"due_date,omitempty"`
-	Status      string `json:"status,omitempty"`
+	ID          string          `json:"id"`
+	Title       string          `json:"title"`
+	Description string          `json:"description,omitempty"`
+	DueDate     string          `json:"due_date,omitempty"`
+	Status      string          `json:"status,omitempty"`
+	Priority    string          `json:"priority,omitempty"`
+	CreatorID   string          `json:"creator_id,omitempty"`
+	OwnerID     string          `json:"owner_id,omitempty"`
+	ProjectID   string          `json:"project_id,omitempty"`
+	SharedWith  []string        `json:"shared_with,omitempty"`
+	Assignees   []string        `json:"assignees,omitempty"`
+	Version     int64           `json:"version"`
+	CreatedAt   string          `json:"created_at,omitempty"`
+	UpdatedAt   string          `json:"updated_at,omitempty"`
+	CreatedBy   string          `json:"created_by,omitempty"`
+	UpdatedBy   string          `json:"updated_by,omitempty"`
+	DeletedAt   string          `json:"deleted_at,omitempty"`
+	DeletedBy   string          `json:"deleted_by,omitempty"`
+	ParentID    string          `json:"parent_id,omitempty"`
+	Checklist   []ChecklistItem `json:"checklist,omitempty"`
+	BlockedBy   []string        `json:"blocked_by,omitempty"`
+	Recurrence  string          `json:"recurrence,omitempty"`
+	SeriesID    string          `json:"series_id,omitempty"`
+	Occurrence  int             `json:"occurrence,omitempty"`
+	Tags        []string        `json:"tags,omitempty"`
+	// Blocked is true while any task in BlockedBy is not done
+	Blocked bool `json:"blocked"`
+	// Progress is the percentage of done children and checklist items,
+	// omitted when the task has neither
+	Progress *int `json:"progress,omitempty"`
+	// Attachments are downloaded from /tasks/:id/attachments/:attachment
+	Attachments []AttachmentResponse `json:"attachments,omitempty"`
+	// AllowedTransitions lists the statuses the task may move to next
+	AllowedTransitions []string `json:"allowed_transitions"`
+}
+
+// TaskChanges is a partial update of a stored task: fields in Set are
+// written, fields named in Unset are removed, and AddToSet/Pull add or
+// remove array elements. Keys are BSON field names. When IfVersion is set the
+// update only applies to that version of the task, and when IfLive is set
+// only to a task that is not in the trash; a trashed task then counts as
+// missing. UpdatedBy is the username stamped on the task by the repository.
+type TaskChanges struct {
+	Set       map[string]interface{}
+	Unset     []string
+	AddToSet  map[string]interface{}
+	Pull      map[string]interface{}
+	IfVersion *int64
+	IfLive    bool
+	UpdatedBy string
+}
+
+// Sort keys accepted by TaskFilter.SortBy
+const (
+	SortByCreatedAt = "created_at"
+	SortByUpdatedAt = "updated_at"
+	SortByDueDate   = "due_date"
+	SortByDeletedAt = "deleted_at"
+	SortByPriority  = "priority" // then by due date
+)
+
+// Page size bounds for task listing
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// TaskFilter narrows the tasks returned by TaskRepository.FindAll.
+// A zero VisibleTo means no ownership restriction (admin view); when set,
+// tasks assigned to VisibleToName and the tasks of the projects in MemberOf
+// are visible as well.
+type TaskFilter struct {
+	VisibleTo     primitive.ObjectID
+	VisibleToName string
+	MemberOf      []primitive.ObjectID
+	ProjectID     primitive.ObjectID
+	Assignee      string
+	Status        string
+	Priority      string
+	DueAfter      *time.Time // inclusive lower bound on due_date
+	DueBefore     *time.Time // inclusive upper bound on due_date
+	Overdue       bool       // due_date in the past and status not "done"
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	CreatedBy     string
+	UpdatedBy     string
+	TitleContains string // case-insensitive substring match
+	ParentID      primitive.ObjectID
+	Tags          []string
+	TagMatch      string // TagMatchAll (default) or TagMatchAny
+	Trashed       bool   // only soft-deleted tasks; otherwise they are excluded
+	SortBy        string // SortByCreatedAt, SortByUpdatedAt, SortByDueDate, SortByDeletedAt or SortByPriority; empty lists the most urgent first
+	SortDesc      bool
+	Limit         int
+	Cursor        string // opaque token from a previous TaskPage.NextCursor
+}
+
+// TaskPage is one page of a keyset-paginated task listing.
+type TaskPage struct {
+	Tasks      []Task
+	NextCursor string
+	HasMore    bool
+}
+
+// PageInfo is the pagination metadata returned to API clients.
+type PageInfo struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// TaskListResponse is the envelope for GET /tasks.
+type TaskListResponse struct {
+	Data       []TaskResponse `json:"data"`
+	Pagination PageInfo       `json:"pagination"`
 }
 
 // User entity
 type User struct {
 	ID           primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	TenantID     primitive.ObjectID `bson:"tenant_id,omitempty" json:"-"`
 	Username     string             `bson:"username" json:"username"`
 	PasswordHash string             `bson:"password_hash" json:"-"`
-	Role         string             `bson:"role" json:"role"` // "admin" or "user"
+	Role         string             `bson:"role" json:"role"` // "admin" (of the organisation) or "user"
+	Timezone     string             `bson:"timezone,omitempty" json:"timezone,omitempty"`
+	Disabled     bool               `bson:"disabled,omitempty" json:"disabled,omitempty"`
+	// TokenVersion goes up whenever the role or the disabled flag changes;
+	// access tokens carrying an older version are refused.
+	TokenVersion int `bson:"token_version,omitempty" json:"-"`
+}
+
+// Actor is the authenticated user a usecase call is made on behalf of.
+type Actor struct {
+	UserID   primitive.ObjectID
+	Username string
+	Role     string
+}
+
+// IsAdmin reports whether the actor holds the admin role.
+func (a Actor) IsAdmin() bool {
+	return a.Role == "admin"
+}
+
+// CanView reports whether the actor may see the task, which belongs to
+// project p. Members of p see every task of the project; tasks shared with or
+// assigned to someone stay visible to them outside of it.
+func (t Task) CanView(a Actor, p Project) bool {
+	if a.IsAdmin() || p.CanView(a) || (t.ProjectID.IsZero() && !t.OwnerID.IsZero() && t.OwnerID == a.UserID) {
+		return true
+	}
+	for _, id := range t.SharedWith {
+		if id == a.UserID {
+			return true
+		}
+	}
+	return t.IsAssigned(a.Username)
+}
+
+// Progress returns the percentage of the task's children and checklist items
+// that are done, or nil when it has neither.
+func (t Task) Progress() *int {
+	total := t.Subtasks.Total + len(t.Checklist)
+	if total == 0 {
+		return nil
+	}
+	done := t.Subtasks.Done
+	for _, item := range t.Checklist {
+		if item.Done {
+			done++
+		}
+	}
+	pct := done * 100 / total
+	return &pct
+}
+
+// IsBlockedBy reports whether id is one of the task's blockers.
+func (t Task) IsBlockedBy(id primitive.ObjectID) bool {
+	for _, b := range t.BlockedBy {
+		if b == id {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDeleted reports whether the task is in the trash.
+func (t Task) IsDeleted() bool {
+	return t.DeletedAt != nil
+}
+
+// IsAssigned reports whether username is one of the task's assignees.
+func (t Task) IsAssigned(username string) bool {
+	if username == "" {
+		return false
+	}
+	for _, u := range t.Assignees {
+		if u == username {
+			return true
+		}
+	}
+	return false
+}
+
+// CanModify reports whether the actor may edit or delete the task, which
+// belongs to project p: admins, and owners and editors of p. Tasks not yet
+// moved into a project can only be changed by their owner.
+func (t Task) CanModify(a Actor, p Project) bool {
+	if t.ProjectID.IsZero() {
+		return a.IsAdmin() || (!t.OwnerID.IsZero() && t.OwnerID == a.UserID)
+	}
+	return p.CanEdit(a)
 }
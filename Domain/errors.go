@@ -0,0 +1,105 @@
+package Domain
+
+import "errors"
+
+// Authorization errors returned by usecases and mapped to 403 by the controller.
+var (
+	ErrForbidden        = errors.New("you do not have permission for this task")
+	ErrNotCommentAuthor = errors.New("only the author can edit a comment")
+	ErrNotProjectOwner  = errors.New("only project owners can manage the project")
+	ErrOwnAccount       = errors.New("admins cannot demote or disable their own account")
+)
+
+// Lookup errors for entities referenced from a request body or path.
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrProjectNotFound = errors.New("project not found")
+)
+
+// Validation errors for list queries, mapped to 400 by the controller.
+var (
+	ErrInvalidQuery  = errors.New("invalid query")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Task field validation errors, mapped to 400 by the controller.
+var (
+	ErrTitleRequired     = errors.New("title required")
+	ErrInvalidDueDate    = errors.New("due_date must be RFC 3339 or YYYY-MM-DD")
+	ErrInvalidTimezone   = errors.New("unknown time zone")
+	ErrUnknownStatus     = errors.New("unknown status")
+	ErrInvalidPriority   = errors.New("priority must be low, medium, high or urgent")
+	ErrNoFieldsToUpdate  = errors.New("no fields to update")
+	ErrInvalidPatch      = errors.New("invalid merge patch")
+	ErrInvalidParent     = errors.New("invalid parent task")
+	ErrInvalidChecklist  = errors.New("invalid checklist")
+	ErrInvalidDependency = errors.New("invalid dependency")
+	ErrInvalidRecurrence = errors.New("invalid recurrence rule")
+	ErrInvalidTag        = errors.New("invalid tag")
+	ErrUnknownTag        = errors.New("unknown tag")
+	ErrInvalidComment    = errors.New("invalid comment")
+	ErrUnknownMention    = errors.New("mentioned user does not exist")
+	ErrInvalidAttachment = errors.New("invalid attachment")
+	ErrInvalidProject    = errors.New("invalid project")
+)
+
+// ErrInvalidOrganization is returned for a bad organisation name; mapped to 400.
+var ErrInvalidOrganization = errors.New("invalid organization")
+
+// Upload limit errors: ErrAttachmentTooLarge is mapped to 413 and
+// ErrUnsupportedMediaType to 415.
+var (
+	ErrAttachmentTooLarge   = errors.New("attachment is too large")
+	ErrUnsupportedMediaType = errors.New("attachment type is not allowed")
+)
+
+// Lookup errors for items embedded in a task, mapped to 404.
+var (
+	ErrChecklistItemNotFound = errors.New("checklist item not found")
+	ErrDependencyNotFound    = errors.New("dependency not found")
+	ErrAttachmentNotFound    = errors.New("attachment not found")
+)
+
+// Dependency conflicts, mapped to 409 by the controller.
+var (
+	ErrDependencyCycle = errors.New("dependency would create a cycle")
+	ErrTaskBlocked     = errors.New("task is blocked by unfinished tasks")
+)
+
+// Project membership conflicts, mapped to 409 by the controller.
+var (
+	ErrProjectNotEmpty  = errors.New("project still has tasks")
+	ErrLastProjectOwner = errors.New("a project needs at least one owner")
+)
+
+// ErrTagExists is returned when a tag name is already in the catalogue; mapped to 409.
+var ErrTagExists = errors.New("a tag with that name already exists")
+
+// ErrNotInTrash is returned when restoring a task that is not deleted; mapped to 409.
+var ErrNotInTrash = errors.New("task is not in the trash")
+
+// ErrOccurrenceExists is returned by TaskRepository.Create when the
+// occurrence of a recurring series was already created.
+var ErrOccurrenceExists = errors.New("occurrence already exists")
+
+// ErrVersionMismatch means the task changed since the client read it; mapped to 412.
+var ErrVersionMismatch = errors.New("task was modified by someone else")
+
+// ErrEditConflict means the task changed while a request without If-Match
+// was being applied; mapped to 409 so the client can simply retry.
+var ErrEditConflict = errors.New("task was modified concurrently, try again")
+
+// ErrNoTenant is returned by repositories called with a context that is not
+// scoped to an organisation (see WithTenant). It indicates a programming error.
+var ErrNoTenant = errors.New("no organization in context")
+
+// ErrOrganizationNotFound is returned when the organisation of a token no
+// longer exists; mapped to 404.
+var ErrOrganizationNotFound = errors.New("organization not found")
+
+// Refresh token errors, mapped to 401. ErrRefreshTokenReused means a token
+// was presented after it had been rotated; its whole family is revoked.
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used")
+)
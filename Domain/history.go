@@ -0,0 +1,55 @@
+package Domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// History actions
+const (
+	HistoryCreated  = "created"
+	HistoryUpdated  = "updated"
+	HistoryDeleted  = "deleted"
+	HistoryRestored = "restored"
+	HistoryPurged   = "purged"
+)
+
+// FieldChange is the before/after value of one task field. A nil side means
+// the field was absent.
+type FieldChange struct {
+	Field  string      `bson:"field" json:"field"`
+	Before interface{} `bson:"before" json:"before"`
+	After  interface{} `bson:"after" json:"after"`
+}
+
+// TaskHistoryEntry records one change to a task. Snapshot is the full task
+// after the change (or as it was when deleted) so any past state can be
+// rebuilt from a single entry.
+type TaskHistoryEntry struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty"`
+	TenantID primitive.ObjectID `bson:"tenant_id,omitempty"`
+	TaskID   primitive.ObjectID `bson:"task_id"`
+	Action   string             `bson:"action"`
+	Actor    string             `bson:"actor"`
+	At       time.Time          `bson:"at"`
+	Version  int64              `bson:"version"`
+	Changes  []FieldChange      `bson:"changes,omitempty"`
+	Snapshot Task               `bson:"snapshot"`
+}
+
+// TaskHistoryResponse is the API representation of a history entry
+type TaskHistoryResponse struct {
+	Action  string        `json:"action"`
+	Actor   string        `json:"actor"`
+	At      string        `json:"at"`
+	Version int64         `json:"version"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// TaskStateResponse is a task as it looked at a point in time
+type TaskStateResponse struct {
+	At      string       `json:"at"`
+	Deleted bool         `json:"deleted"`
+	Task    TaskResponse `json:"task"`
+}
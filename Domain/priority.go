@@ -0,0 +1,40 @@
+package Domain
+
+// Task priorities, lowest first
+const (
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+// DefaultPriority is given to tasks created without one.
+const DefaultPriority = PriorityMedium
+
+// priorityRanks orders the priorities; the rank is stored next to the
+// priority so tasks can be sorted by it.
+var priorityRanks = map[string]int{
+	PriorityLow:    1,
+	PriorityMedium: 2,
+	PriorityHigh:   3,
+	PriorityUrgent: 4,
+}
+
+// PriorityRank returns the sort rank of a priority, or 0 when it is unknown.
+func PriorityRank(priority string) int {
+	return priorityRanks[priority]
+}
+
+// PriorityUpdate is the admin payload for re-prioritising many tasks at once.
+type PriorityUpdate struct {
+	TaskIDs  []string `json:"task_ids"`
+	Priority string   `json:"priority"`
+}
+
+// PriorityUpdateResult reports what a bulk re-prioritisation did with each task.
+type PriorityUpdateResult struct {
+	Updated   []string `json:"updated"`
+	Unchanged []string `json:"unchanged"`
+	NotFound  []string `json:"not_found"`
+	Failed    []string `json:"failed"`
+}
@@ -0,0 +1,131 @@
+package Domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Project member roles. Viewers can read every task of the project, editors
+// can also create and change them, and owners can manage the project itself.
+const (
+	ProjectOwner  = "owner"
+	ProjectEditor = "editor"
+	ProjectViewer = "viewer"
+)
+
+// MaxProjectNameLength bounds the length of a project name in characters.
+const MaxProjectNameLength = 100
+
+// PersonalProjectName names the project holding the tasks a user creates
+// without choosing a project.
+const PersonalProjectName = "Personal"
+
+// Project groups tasks and controls who can see and change them.
+type Project struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	TenantID    primitive.ObjectID `bson:"tenant_id,omitempty"`
+	Name        string             `bson:"name"`
+	Description string             `bson:"description,omitempty"`
+	Members     []ProjectMember    `bson:"members"`
+	// PersonalOf is set on a user's personal project
+	PersonalOf primitive.ObjectID `bson:"personal_of,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at"`
+	CreatedBy  string             `bson:"created_by,omitempty"`
+	UpdatedAt  time.Time          `bson:"updated_at"`
+}
+
+// ProjectMember is a user's role in a project
+type ProjectMember struct {
+	UserID   primitive.ObjectID `bson:"user_id"`
+	Username string             `bson:"username"`
+	Role     string             `bson:"role"`
+}
+
+// ProjectInput is the client payload for creating or updating a project.
+type ProjectInput struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// ProjectMemberInput is the client payload for adding a member or changing their role.
+type ProjectMemberInput struct {
+	Role string `json:"role"`
+}
+
+// ProjectMemberResponse for API (ID as hex)
+type ProjectMemberResponse struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// ProjectResponse for API (ID as hex)
+type ProjectResponse struct {
+	ID          string                  `json:"id"`
+	Name        string                  `json:"name"`
+	Description string                  `json:"description,omitempty"`
+	Personal    bool                    `json:"personal"`
+	Members     []ProjectMemberResponse `json:"members"`
+	CreatedAt   string                  `json:"created_at,omitempty"`
+	CreatedBy   string                  `json:"created_by,omitempty"`
+	UpdatedAt   string                  `json:"updated_at,omitempty"`
+}
+
+// NormalizeProjectName trims a project name and checks its length.
+func NormalizeProjectName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxProjectNameLength {
+		return "", fmt.Errorf("%w: names must be 1 to %d characters", ErrInvalidProject, MaxProjectNameLength)
+	}
+	return name, nil
+}
+
+// IsProjectRole reports whether role is one of the project member roles.
+func IsProjectRole(role string) bool {
+	return role == ProjectOwner || role == ProjectEditor || role == ProjectViewer
+}
+
+// RoleOf returns the user's role in the project, or "" for non-members.
+func (p Project) RoleOf(userID primitive.ObjectID) string {
+	if userID.IsZero() {
+		return ""
+	}
+	for _, m := range p.Members {
+		if m.UserID == userID {
+			return m.Role
+		}
+	}
+	return ""
+}
+
+// Owners counts the members holding the owner role.
+func (p Project) Owners() int {
+	n := 0
+	for _, m := range p.Members {
+		if m.Role == ProjectOwner {
+			n++
+		}
+	}
+	return n
+}
+
+// CanView reports whether the actor may see the project and its tasks.
+func (p Project) CanView(a Actor) bool {
+	return a.IsAdmin() || p.RoleOf(a.UserID) != ""
+}
+
+// CanEdit reports whether the actor may create and change tasks of the project.
+func (p Project) CanEdit(a Actor) bool {
+	role := p.RoleOf(a.UserID)
+	return a.IsAdmin() || role == ProjectOwner || role == ProjectEditor
+}
+
+// CanManage reports whether the actor may rename or delete the project and
+// manage its members.
+func (p Project) CanManage(a Actor) bool {
+	return a.IsAdmin() || p.RoleOf(a.UserID) == ProjectOwner
+}
@@ -0,0 +1,429 @@
+package Domain
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Recurrence frequencies supported in RRULE FREQ
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+	FreqYearly  = "YEARLY"
+)
+
+// maxRecurrencePeriods bounds the search for the next occurrence so that
+// rules which can never match (e.g. BYMONTH=2;BYMONTHDAY=30) terminate.
+const maxRecurrencePeriods = 5000
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// ByDay is a BYDAY entry: a weekday, optionally limited to its Nth (or, if
+// negative, Nth from last) occurrence in the month.
+type ByDay struct {
+	Weekday time.Weekday
+	N       int
+}
+
+func (d ByDay) String() string {
+	code := strings.ToUpper(d.Weekday.String()[:2])
+	if d.N == 0 {
+		return code
+	}
+	return strconv.Itoa(d.N) + code
+}
+
+// RRule is the subset of an RFC 5545 recurrence rule supported for tasks:
+// FREQ, INTERVAL, BYDAY, BYMONTHDAY, BYMONTH, COUNT, UNTIL and WKST.
+type RRule struct {
+	Freq       string
+	Interval   int
+	ByDay      []ByDay
+	ByMonthDay []int
+	ByMonth    []int
+	Count      int
+	Until      *time.Time
+	UntilDate  bool // UNTIL was a date and covers that whole day
+	WeekStart  time.Weekday
+}
+
+// ParseRRule parses a rule such as "FREQ=WEEKLY;BYDAY=MO,WE" or
+// "RRULE:FREQ=MONTHLY;BYDAY=-1FR". A date-only UNTIL is kept as a calendar
+// date and covers that whole day in the series' time zone.
+func ParseRRule(spec string) (RRule, error) {
+	r := RRule{Interval: 1, WeekStart: time.Monday}
+	spec = strings.TrimPrefix(strings.TrimSpace(spec), "RRULE:")
+	if spec == "" {
+		return RRule{}, fmt.Errorf("%w: empty rule", ErrInvalidRecurrence)
+	}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(spec, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || value == "" {
+			return RRule{}, fmt.Errorf("%w: malformed part %q", ErrInvalidRecurrence, part)
+		}
+		if seen[name] {
+			return RRule{}, fmt.Errorf("%w: %s given twice", ErrInvalidRecurrence, name)
+		}
+		seen[name] = true
+		var err error
+		switch name {
+		case "FREQ":
+			switch value {
+			case FreqDaily, FreqWeekly, FreqMonthly, FreqYearly:
+				r.Freq = value
+			default:
+				err = fmt.Errorf("unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = parseRuleInt(value, 1, 1000)
+		case "COUNT":
+			r.Count, err = parseRuleInt(value, 1, 100000)
+		case "UNTIL":
+			r.Until, r.UntilDate, err = parseUntil(value)
+		case "WKST":
+			day, ok := weekdayCodes[value]
+			if !ok {
+				err = fmt.Errorf("invalid WKST %q", value)
+			}
+			r.WeekStart = day
+		case "BYDAY":
+			for _, v := range strings.Split(value, ",") {
+				var d ByDay
+				if d, err = parseByDay(v); err != nil {
+					break
+				}
+				r.ByDay = append(r.ByDay, d)
+			}
+		case "BYMONTHDAY":
+			for _, v := range strings.Split(value, ",") {
+				var n int
+				if n, err = parseRuleInt(strings.TrimPrefix(v, "-"), 1, 31); err != nil {
+					break
+				}
+				if strings.HasPrefix(v, "-") {
+					n = -n
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		case "BYMONTH":
+			for _, v := range strings.Split(value, ",") {
+				var n int
+				if n, err = parseRuleInt(v, 1, 12); err != nil {
+					break
+				}
+				r.ByMonth = append(r.ByMonth, n)
+			}
+		default:
+			err = fmt.Errorf("%s is not supported", name)
+		}
+		if err != nil {
+			return RRule{}, fmt.Errorf("%w: %v", ErrInvalidRecurrence, err)
+		}
+	}
+	if r.Freq == "" {
+		return RRule{}, fmt.Errorf("%w: FREQ is required", ErrInvalidRecurrence)
+	}
+	if r.Count > 0 && r.Until != nil {
+		return RRule{}, fmt.Errorf("%w: COUNT and UNTIL cannot both be set", ErrInvalidRecurrence)
+	}
+	for _, d := range r.ByDay {
+		if d.N == 0 {
+			continue
+		}
+		if r.Freq != FreqMonthly && r.Freq != FreqYearly {
+			return RRule{}, fmt.Errorf("%w: BYDAY=%s needs FREQ=MONTHLY or YEARLY", ErrInvalidRecurrence, d)
+		}
+		if r.Freq == FreqYearly && len(r.ByMonth) == 0 {
+			return RRule{}, fmt.Errorf("%w: yearly BYDAY=%s needs BYMONTH", ErrInvalidRecurrence, d)
+		}
+		if d.N < -5 || d.N > 5 {
+			return RRule{}, fmt.Errorf("%w: BYDAY=%s is out of range", ErrInvalidRecurrence, d)
+		}
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq == FreqWeekly {
+		return RRule{}, fmt.Errorf("%w: BYMONTHDAY cannot be used with FREQ=WEEKLY", ErrInvalidRecurrence)
+	}
+	return r, nil
+}
+
+func parseRuleInt(value string, min, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("%q must be between %d and %d", value, min, max)
+	}
+	return n, nil
+}
+
+func parseByDay(value string) (ByDay, error) {
+	if len(value) < 2 {
+		return ByDay{}, fmt.Errorf("invalid BYDAY %q", value)
+	}
+	day, ok := weekdayCodes[value[len(value)-2:]]
+	if !ok {
+		return ByDay{}, fmt.Errorf("invalid BYDAY %q", value)
+	}
+	d := ByDay{Weekday: day}
+	if prefix := value[:len(value)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 {
+			return ByDay{}, fmt.Errorf("invalid BYDAY %q", value)
+		}
+		d.N = n
+	}
+	return d, nil
+}
+
+func parseUntil(value string) (*time.Time, bool, error) {
+	if ts, err := time.Parse("20060102T150405Z", value); err == nil {
+		return &ts, false, nil
+	}
+	if ts, err := time.Parse("20060102", value); err == nil {
+		return &ts, true, nil
+	}
+	return nil, false, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+// String returns the rule in a canonical form, without the RRULE: prefix.
+func (r RRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, d := range r.ByDay {
+			days = append(days, d.String())
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByMonth) > 0 {
+		parts = append(parts, "BYMONTH="+joinInts(r.ByMonth))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		if r.UntilDate {
+			parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+		}
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+ByDay{Weekday: r.WeekStart}.String())
+	}
+	return strings.Join(parts, ";")
+}
+
+func joinInts(ns []int) string {
+	s := make([]string, 0, len(ns))
+	for _, n := range ns {
+		s = append(s, strconv.Itoa(n))
+	}
+	return strings.Join(s, ",")
+}
+
+// Next returns the first occurrence of the series starting at start that is
+// strictly after after. The rule is evaluated in loc and occurrences keep the
+// wall-clock time of start. index is the 1-based position of the occurrence
+// at after, used to honour COUNT. It returns false once the series has ended.
+func (r RRule) Next(start, after time.Time, index int, loc *time.Location) (time.Time, bool) {
+	if r.Count > 0 && index >= r.Count {
+		return time.Time{}, false
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	start = start.In(loc)
+	after = after.In(loc)
+	k := r.firstPeriod(start, after)
+	for i := 0; i < maxRecurrencePeriods; i, k = i+1, k+r.Interval {
+		for _, c := range r.expand(start, k) {
+			if c.Before(start) || !c.After(after) {
+				continue
+			}
+			if r.Until != nil && c.After(r.until(loc)) {
+				return time.Time{}, false
+			}
+			return c.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// until returns the last instant covered by UNTIL in loc.
+func (r RRule) until(loc *time.Location) time.Time {
+	if r.UntilDate {
+		u := *r.Until
+		return time.Date(u.Year(), u.Month(), u.Day(), 23, 59, 59, 0, loc)
+	}
+	return *r.Until
+}
+
+// firstPeriod returns the index, in units of FREQ from start, of the first
+// period that can contain an occurrence after after. It is aligned to INTERVAL.
+func (r RRule) firstPeriod(start, after time.Time) int {
+	var k int
+	switch r.Freq {
+	case FreqDaily:
+		k = daysBetween(start, after)
+	case FreqWeekly:
+		k = daysBetween(r.weekOf(start), after) / 7
+	case FreqMonthly:
+		k = (after.Year()-start.Year())*12 + int(after.Month()-start.Month())
+	case FreqYearly:
+		k = after.Year() - start.Year()
+	}
+	if k < 0 {
+		return 0
+	}
+	return k - k%r.Interval
+}
+
+func daysBetween(a, b time.Time) int {
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(db.Sub(da).Hours() / 24)
+}
+
+// weekOf returns midnight of the first day (WKST) of the week containing t.
+func (r RRule) weekOf(t time.Time) time.Time {
+	offset := (int(t.Weekday()) - int(r.WeekStart) + 7) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
+
+// expand returns the sorted occurrences in the k-th period after start.
+func (r RRule) expand(start time.Time, k int) []time.Time {
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+	}
+	var days []time.Time
+	switch r.Freq {
+	case FreqDaily:
+		days = []time.Time{at(start.Year(), start.Month(), start.Day()+k)}
+	case FreqWeekly:
+		week := r.weekOf(start).AddDate(0, 0, 7*k)
+		weekdays := r.ByDay
+		if len(weekdays) == 0 {
+			weekdays = []ByDay{{Weekday: start.Weekday()}}
+		}
+		for i := 0; i < 7; i++ {
+			d := at(week.Year(), week.Month(), week.Day()+i)
+			for _, wd := range weekdays {
+				if d.Weekday() == wd.Weekday {
+					days = append(days, d)
+				}
+			}
+		}
+	case FreqMonthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(k), 1, 0, 0, 0, 0, start.Location())
+		days = r.expandMonth(start, first.Year(), first.Month(), at)
+	case FreqYearly:
+		year := start.Year() + k
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []int{int(start.Month())}
+		}
+		for _, m := range months {
+			days = append(days, r.expandMonth(start, year, time.Month(m), at)...)
+		}
+	}
+
+	out := days[:0]
+	for _, d := range days {
+		if r.matches(d) {
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return out
+}
+
+// expandMonth returns the candidate days of one month for MONTHLY and YEARLY
+// rules: BYMONTHDAY and BYDAY, or the day of month of start.
+func (r RRule) expandMonth(start time.Time, year int, month time.Month, at func(int, time.Month, int) time.Time) []time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	var days []time.Time
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, n := range r.ByMonthDay {
+			if n < 0 {
+				n = last + 1 + n
+			}
+			if n >= 1 && n <= last {
+				days = append(days, at(year, month, n))
+			}
+		}
+	case len(r.ByDay) > 0:
+		for day := 1; day <= last; day++ {
+			days = append(days, at(year, month, day))
+		}
+	default:
+		if start.Day() <= last {
+			days = append(days, at(year, month, start.Day()))
+		}
+	}
+	return days
+}
+
+// matches applies the BYMONTH, BYMONTHDAY and BYDAY limits to a candidate day.
+func (r RRule) matches(d time.Time) bool {
+	if len(r.ByMonth) > 0 && !containsInt(r.ByMonth, int(d.Month())) {
+		return false
+	}
+	if len(r.ByMonthDay) > 0 {
+		last := time.Date(d.Year(), d.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		if !containsInt(r.ByMonthDay, d.Day()) && !containsInt(r.ByMonthDay, d.Day()-last-1) {
+			return false
+		}
+	}
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	last := time.Date(d.Year(), d.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	nth := (d.Day()-1)/7 + 1
+	nthFromEnd := -((last-d.Day())/7 + 1)
+	for _, bd := range r.ByDay {
+		if bd.Weekday == d.Weekday() && (bd.N == 0 || bd.N == nth || bd.N == nthFromEnd) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(ns []int, n int) bool {
+	for _, v := range ns {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+// TaskRecurrence makes a task one occurrence of a repeating series. Start is
+// the due date of the first occurrence and Timezone the zone the rule is
+// evaluated in. NextCreated is set once the following occurrence exists or
+// the series has ended.
+type TaskRecurrence struct {
+	Rule        string             `bson:"rule"`
+	Start       time.Time          `bson:"start"`
+	Timezone    string             `bson:"tz,omitempty"`
+	SeriesID    primitive.ObjectID `bson:"series_id"`
+	Index       int                `bson:"index"`
+	NextCreated bool               `bson:"next_created,omitempty"`
+}
@@ -0,0 +1,28 @@
+package Domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefreshToken is the stored half of an opaque refresh token. Only a hash
+// of the token is kept. Every refresh replaces the token with a new one of
+// the same family; presenting a replaced token again revokes the family.
+type RefreshToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	TenantID  primitive.ObjectID `bson:"tenant_id,omitempty"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	Username  string             `bson:"username"`
+	FamilyID  primitive.ObjectID `bson:"family_id"` // shared by every rotation of one login
+	Hash      string             `bson:"hash"`
+	CreatedAt time.Time          `bson:"created_at"`
+	ExpiresAt time.Time          `bson:"expires_at"`
+	UsedAt    *time.Time         `bson:"used_at,omitempty"`    // set once it was exchanged
+	RevokedAt *time.Time         `bson:"revoked_at,omitempty"` // set when its family was revoked
+}
+
+// Usable reports whether the token can still be exchanged at now.
+func (t RefreshToken) Usable(now time.Time) bool {
+	return t.UsedAt == nil && t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,75 @@
+package Domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxTagLength bounds the length of a tag name in characters.
+const MaxTagLength = 50
+
+// Tag match modes accepted by TaskFilter.TagMatch
+const (
+	TagMatchAll = "all"
+	TagMatchAny = "any"
+)
+
+var tagColorPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+// Tag is an entry of an organisation's tag catalogue. Tasks refer to tags by name.
+type Tag struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	TenantID  primitive.ObjectID `bson:"tenant_id,omitempty"`
+	Name      string             `bson:"name"`
+	Color     string             `bson:"color,omitempty"`
+	CreatedAt time.Time          `bson:"created_at"`
+	CreatedBy string             `bson:"created_by,omitempty"`
+}
+
+// TagInput is the client payload for creating or updating a tag.
+// Color is an optional #rrggbb value.
+type TagInput struct {
+	Name  string `json:"name"`
+	Color string `json:"color,omitempty"`
+}
+
+// TagResponse for API (ID as hex)
+type TagResponse struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color,omitempty"`
+}
+
+// NormalizeTag trims and lower-cases a tag name so that names compare
+// case-insensitively.
+func NormalizeTag(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || utf8.RuneCountInString(name) > MaxTagLength {
+		return "", fmt.Errorf("%w: names must be 1 to %d characters", ErrInvalidTag, MaxTagLength)
+	}
+	return name, nil
+}
+
+// NormalizeTagColor lower-cases a #rrggbb color; an empty color is allowed.
+func NormalizeTagColor(color string) (string, error) {
+	color = strings.ToLower(strings.TrimSpace(color))
+	if color != "" && !tagColorPattern.MatchString(color) {
+		return "", fmt.Errorf("%w: color must be #rrggbb", ErrInvalidTag)
+	}
+	return color, nil
+}
+
+// HasTag reports whether the task carries the named tag.
+func (t Task) HasTag(name string) bool {
+	for _, tag := range t.Tags {
+		if tag == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package Domain
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxOrganizationNameLength bounds the length of an organisation name in characters.
+const MaxOrganizationNameLength = 100
+
+// Organization is a tenant: a team whose users, projects, tags and tasks are
+// isolated from every other organisation hosted on the deployment. Users with
+// the admin role administer their own organisation only. Default marks the
+// one organisation that documents stored before tenancy were moved into.
+type Organization struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Name      string             `bson:"name"`
+	Default   bool               `bson:"default,omitempty"`
+	CreatedAt time.Time          `bson:"created_at"`
+	CreatedBy string             `bson:"created_by,omitempty"`
+}
+
+// OrganizationResponse for API (ID as hex)
+type OrganizationResponse struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at,omitempty"`
+	CreatedBy string `json:"created_by,omitempty"`
+}
+
+// NormalizeOrganizationName trims an organisation name and checks its length.
+func NormalizeOrganizationName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxOrganizationNameLength {
+		return "", fmt.Errorf("%w: names must be 1 to %d characters", ErrInvalidOrganization, MaxOrganizationNameLength)
+	}
+	return name, nil
+}
+
+type tenantKey struct{}
+
+type tenantScope struct {
+	id  primitive.ObjectID
+	all bool
+}
+
+// WithTenant scopes ctx to the organisation id. Repositories only read and
+// write documents of that organisation.
+func WithTenant(ctx context.Context, id primitive.ObjectID) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantScope{id: id})
+}
+
+// AllTenants lifts the tenant scope of ctx. It is meant for logins and
+// background jobs that work across organisations, never for client requests.
+func AllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantScope{all: true})
+}
+
+// TenantFromContext returns the organisation ctx is scoped to. all is true
+// for contexts made by AllTenants; ok is false for unscoped contexts.
+func TenantFromContext(ctx context.Context) (id primitive.ObjectID, all, ok bool) {
+	scope, ok := ctx.Value(tenantKey{}).(tenantScope)
+	return scope.id, scope.all, ok
+}
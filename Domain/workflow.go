@@ -0,0 +1,126 @@
+package Domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Built-in task statuses
+const (
+	StatusTodo       = "todo"
+	StatusInProgress = "in_progress"
+	StatusReview     = "review"
+	StatusDone       = "done"
+)
+
+// Workflow describes the statuses a task can be in and which status changes
+// are allowed. States lists every status in display order.
+type Workflow struct {
+	Initial     string              `json:"initial"`
+	States      []string            `json:"states"`
+	Transitions map[string][]string `json:"transitions"`
+}
+
+// DefaultWorkflow is todo → in_progress → review → done, with review able to
+// go back to in_progress. allowReopen additionally permits done → todo.
+func DefaultWorkflow(allowReopen bool) Workflow {
+	w := Workflow{
+		Initial: StatusTodo,
+		States:  []string{StatusTodo, StatusInProgress, StatusReview, StatusDone},
+		Transitions: map[string][]string{
+			StatusTodo:       {StatusInProgress},
+			StatusInProgress: {StatusTodo, StatusReview},
+			StatusReview:     {StatusInProgress, StatusDone},
+			StatusDone:       {},
+		},
+	}
+	if allowReopen {
+		w.Transitions[StatusDone] = []string{StatusTodo}
+	}
+	return w
+}
+
+// ParseWorkflow reads a workflow from a spec such as
+// "todo>in_progress; in_progress>review,todo; review>done,in_progress; done".
+// The first state listed is the initial one and the workflow must contain StatusDone.
+func ParseWorkflow(spec string) (Workflow, error) {
+	w := Workflow{Transitions: map[string][]string{}}
+	seen := map[string]bool{}
+	addState := func(s string) {
+		if !seen[s] {
+			seen[s] = true
+			w.States = append(w.States, s)
+		}
+	}
+	for _, rule := range strings.Split(spec, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		from, to, _ := strings.Cut(rule, ">")
+		from = strings.TrimSpace(from)
+		if from == "" {
+			return Workflow{}, fmt.Errorf("workflow rule %q has no source state", rule)
+		}
+		addState(from)
+		if w.Initial == "" {
+			w.Initial = from
+		}
+		next := w.Transitions[from]
+		for _, s := range strings.Split(to, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				addState(s)
+				next = append(next, s)
+			}
+		}
+		w.Transitions[from] = next
+	}
+	if w.Initial == "" {
+		return Workflow{}, errors.New("workflow has no states")
+	}
+	if !seen[StatusDone] {
+		return Workflow{}, fmt.Errorf("workflow must include the %q state", StatusDone)
+	}
+	return w, nil
+}
+
+// IsState reports whether s is a status of the workflow.
+func (w Workflow) IsState(s string) bool {
+	for _, state := range w.States {
+		if state == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns the statuses a task in status from may move to. Statuses
+// outside the workflow (legacy data) may move to any workflow status.
+func (w Workflow) Next(from string) []string {
+	if !w.IsState(from) {
+		return append([]string{}, w.States...)
+	}
+	return append([]string{}, w.Transitions[from]...)
+}
+
+// CanTransition reports whether moving from one status to another is allowed.
+func (w Workflow) CanTransition(from, to string) bool {
+	for _, s := range w.Next(from) {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionError is returned when a status change is not allowed by the workflow.
+type TransitionError struct {
+	From    string
+	To      string
+	Allowed []string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot move task from %q to %q", e.From, e.To)
+}
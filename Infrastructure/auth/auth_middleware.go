@@ -3,19 +3,35 @@ package auth
 import (
 	"net/http"
 	"strings"
-	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 type AuthMiddleware struct {
-	secret string
+	jwt     *JWTService
+	revoked RevocationStore
+	users   *userCache
 }
 
-func NewAuthMiddleware() *AuthMiddleware {
-	return &AuthMiddleware{
-		secret: os.Getenv("JWT_SECRET"),
+// NewAuthMiddleware verifies tokens with the keys of svc, checks them
+// against revoked and checks that their user still exists, is enabled and
+// has the role the token was issued for. A nil store or lookup skips that check.
+func NewAuthMiddleware(svc *JWTService, revoked RevocationStore, users UserLookup) *AuthMiddleware {
+	m := &AuthMiddleware{
+		jwt:     svc,
+		revoked: revoked,
+	}
+	if users != nil {
+		m.users = newUserCache(users)
+	}
+	return m
+}
+
+// SetUserCacheTTL changes how long user records are cached between checks.
+func (m *AuthMiddleware) SetUserCacheTTL(ttl time.Duration) {
+	if m.users != nil {
+		m.users.setTTL(ttl)
 	}
 }
 
@@ -36,19 +52,47 @@ func (m *AuthMiddleware) Handle() gin.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.Parse(parts[1], func(t *jwt.Token) (interface{}, error) {
-			return []byte(m.secret), nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := m.jwt.ValidateToken(parts[1])
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
 			c.Abort()
 			return
 		}
-
-		claims := token.Claims.(jwt.MapClaims)
-		c.Set("username", claims["username"])
-		c.Set("role", claims["role"])
+		principal, err := principalFromClaims(claims)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+			c.Abort()
+			return
+		}
+		if m.revoked != nil {
+			revoked, err := m.revoked.IsRevoked(c.Request.Context(), principal.TokenID, principal.UserID.Hex(), principal.IssuedAt)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check token"})
+				c.Abort()
+				return
+			}
+			if revoked {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
+				c.Abort()
+				return
+			}
+		}
+		if m.users != nil {
+			u, err := m.users.get(c.Request.Context(), principal)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check token"})
+				c.Abort()
+				return
+			}
+			// a role change or a disabled account bumps the version
+			if u.ID != principal.UserID || u.Disabled || u.TokenVersion != principal.Version {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "token is no longer valid"})
+				c.Abort()
+				return
+			}
+			principal.Role = u.Role
+		}
+		SetPrincipal(c, principal)
 
 		c.Next()
 	}
@@ -56,8 +100,7 @@ func (m *AuthMiddleware) Handle() gin.HandlerFunc {
 
 func (m *AuthMiddleware) RequireAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role := c.GetString("role")
-		if role != "admin" {
+		if p, ok := PrincipalFrom(c); !ok || !p.IsAdmin() {
 			c.JSON(http.StatusForbidden, gin.H{"error": "admin-only route"})
 			c.Abort()
 			return
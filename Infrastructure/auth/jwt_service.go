@@ -1,8 +1,9 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
-	"fmt"
 	"os"
 	"time"
 
@@ -10,13 +11,39 @@ import (
 )
 
 
+// JWTService issues and checks access tokens, signed either with a shared
+// secret (HS256) or with a key pair (see NewAsymmetricJWTService).
 type JWTService struct {
 	secret []byte 
+	ttl    time.Duration
+
+	issuer   string
+	audience string
+	leeway   time.Duration // clock skew tolerated on exp, nbf and iat
+
+	signing *SigningKey
+	method  jwt.SigningMethod
+	keys    map[string]VerificationKey // by kid
 }
 
+// DefaultAccessTokenTTL is the lifetime of access tokens; clients renew
+// them with a refresh token.
+const DefaultAccessTokenTTL = 15 * time.Minute
+
+// Defaults for the iss and aud claims, used unless JWT_ISSUER or
+// JWT_AUDIENCE are set, and for the clock-skew leeway (see SetTokenTiming).
+const (
+	DefaultIssuer   = "task_manager"
+	DefaultAudience = "task_manager"
+	DefaultLeeway   = 30 * time.Second
+)
+
 type Claims struct {
+	UserID   string `json:"user_id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	TenantID string `json:"tenant_id"`
+	Version  int    `json:"ver,omitempty"` // the user's TokenVersion when issued
 	jwt.RegisteredClaims
 }
 
@@ -26,36 +53,73 @@ func NewJWTService() *JWTService {
 		
 		secret = "default_secret_key" 
 	}
-	return &JWTService{
-		secret: []byte(secret),
+	j := &JWTService{secret: []byte(secret)}
+	j.configure()
+	return j
+}
+
+// configure reads the claims tokens must carry (JWT_ISSUER, JWT_AUDIENCE)
+// from the environment and starts from the default token timing.
+func (j *JWTService) configure() {
+	j.ttl, j.issuer, j.audience, j.leeway = DefaultAccessTokenTTL, DefaultIssuer, DefaultAudience, DefaultLeeway
+	if v := os.Getenv("JWT_ISSUER"); v != "" {
+		j.issuer = v
+	}
+	if v := os.Getenv("JWT_AUDIENCE"); v != "" {
+		j.audience = v
 	}
 }
 
-// GenerateToken creates a signed JWT string
-func (j *JWTService) GenerateToken(username, role string) (string, error) {
+// SetTokenTiming changes how long issued tokens are valid and the clock skew
+// tolerated when checking them.
+func (j *JWTService) SetTokenTiming(ttl, leeway time.Duration) {
+	j.ttl, j.leeway = ttl, leeway
+}
+
+// TTL returns how long the access tokens it issues are valid.
+func (j *JWTService) TTL() time.Duration {
+	return j.ttl
+}
+
+// GenerateToken creates a signed JWT string for a user of the organisation
+// tenantID whose TokenVersion is version
+func (j *JWTService) GenerateToken(userID, username, role, tenantID string, version int) (string, error) {
+	// a unique jti lets a single token be revoked
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+	now := time.Now()
 	claims := Claims{
+		UserID:   userID,
 		Username: username,
 		Role:     role,
+		TenantID: tenantID,
+		Version:  version,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        hex.EncodeToString(jti),
+			Issuer:    j.issuer,
+			Audience:  jwt.ClaimStrings{j.audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(j.ttl)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(j.secret)
+	return j.sign(claims)
 }
 
-
+// ValidateToken checks the signature, the signing algorithm, exp, nbf and
+// iat (with the configured leeway), the issuer and the audience.
 func (j *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-	
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return j.secret, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, j.keyFor,
+		jwt.WithIssuer(j.issuer),
+		jwt.WithAudience(j.audience),
+		jwt.WithLeeway(j.leeway),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	)
 
 
 	if err != nil {
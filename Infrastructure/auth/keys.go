@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSABits is the smallest RSA modulus accepted for signing tokens.
+const minRSABits = 2048
+
+var errUnsupportedKey = errors.New("unsupported key: use RSA (at least 2048 bits) or Ed25519")
+
+// SigningKey is the private key tokens are signed with. Its ID is sent as
+// the kid header so verifiers can pick the matching public key.
+type SigningKey struct {
+	ID  string
+	Key crypto.Signer // *rsa.PrivateKey or ed25519.PrivateKey
+}
+
+// VerificationKey is a public key tokens may be signed with. Retired
+// signing keys stay verification keys until their tokens have expired.
+type VerificationKey struct {
+	ID  string
+	Key crypto.PublicKey // *rsa.PublicKey or ed25519.PublicKey
+}
+
+// JWK is the JSON Web Key (RFC 7517) form of a verification key.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewAsymmetricJWTService signs tokens with signing and accepts tokens
+// signed by it or by any of verify. Shared-secret tokens are rejected.
+func NewAsymmetricJWTService(signing SigningKey, verify ...VerificationKey) (*JWTService, error) {
+	method, err := signingMethod(signing.Key.Public())
+	if err != nil {
+		return nil, err
+	}
+	if signing.ID == "" {
+		if signing.ID, err = keyID(signing.Key.Public()); err != nil {
+			return nil, err
+		}
+	}
+	j := &JWTService{
+		signing: &signing,
+		method:  method,
+		keys:    map[string]VerificationKey{signing.ID: {ID: signing.ID, Key: signing.Key.Public()}},
+	}
+	j.configure()
+	for _, k := range verify {
+		if _, err := signingMethod(k.Key); err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.ID, err)
+		}
+		if k.ID == "" {
+			if k.ID, err = keyID(k.Key); err != nil {
+				return nil, err
+			}
+		}
+		if _, dup := j.keys[k.ID]; dup {
+			return nil, fmt.Errorf("duplicate key id %q", k.ID)
+		}
+		j.keys[k.ID] = k
+	}
+	return j, nil
+}
+
+// LoadSigningKey reads a PEM encoded RSA or Ed25519 private key. An empty
+// id is replaced by one derived from the public key.
+func LoadSigningKey(id, path string) (SigningKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return SigningKey{}, err
+	}
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return SigningKey{}, fmt.Errorf("%s: unexpected PEM block %q", path, block.Type)
+	}
+	if err != nil {
+		return SigningKey{}, fmt.Errorf("%s: %w", path, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return SigningKey{}, fmt.Errorf("%s: %w", path, errUnsupportedKey)
+	}
+	if _, err := signingMethod(signer.Public()); err != nil {
+		return SigningKey{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return SigningKey{ID: id, Key: signer}, nil
+}
+
+// LoadVerificationKey reads a PEM encoded public key, or takes the public
+// half of a private key.
+func LoadVerificationKey(id, path string) (VerificationKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return VerificationKey{}, err
+	}
+	var key any
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		signing, err := LoadSigningKey(id, path)
+		if err != nil {
+			return VerificationKey{}, err
+		}
+		return VerificationKey{ID: id, Key: signing.Key.Public()}, nil
+	}
+	if err != nil {
+		return VerificationKey{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if _, err := signingMethod(key); err != nil {
+		return VerificationKey{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return VerificationKey{ID: id, Key: key}, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", path)
+	}
+	return block, nil
+}
+
+// signingMethod returns the only algorithm a key may be used with, so a
+// token cannot pick a weaker one through its alg header.
+func signingMethod(key crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSABits {
+			return nil, errUnsupportedKey
+		}
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, errUnsupportedKey
+	}
+}
+
+// keyID derives a stable kid from the SHA-256 of the public key.
+func keyID(key crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:12]), nil
+}
+
+// sign signs claims with the signing key, or the shared secret when the
+// service has no key pair.
+func (j *JWTService) sign(claims Claims) (string, error) {
+	if j.signing == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secret)
+	}
+	token := jwt.NewWithClaims(j.method, claims)
+	token.Header["kid"] = j.signing.ID
+	return token.SignedString(j.signing.Key)
+}
+
+// keyFor picks the key a token is verified with from its kid header and
+// rejects tokens whose alg does not belong to that key.
+func (j *JWTService) keyFor(token *jwt.Token) (interface{}, error) {
+	if j.signing == nil {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return j.secret, nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, ok := j.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	method, err := signingMethod(key.Key)
+	if err != nil {
+		return nil, err
+	}
+	if token.Method.Alg() != method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.Key, nil
+}
+
+// JWKS returns the verification keys for other services. It is empty when
+// tokens are signed with a shared secret, which must never be published.
+func (j *JWTService) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, k := range j.keys {
+		method, _ := signingMethod(k.Key)
+		jwk := JWK{Kid: k.ID, Use: "sig", Alg: method.Alg()}
+		switch pub := k.Key.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(a, b int) bool { return set.Keys[a].Kid < set.Keys[b].Kid })
+	return set
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const principalKey = "principal"
+
+var errIncompleteClaims = errors.New("token lacks user or organization claims")
+
+// Principal is the authenticated caller of a request, taken from a verified
+// access token.
+type Principal struct {
+	UserID    primitive.ObjectID
+	TenantID  primitive.ObjectID
+	Username  string
+	Role      string
+	Version   int    // TokenVersion of the user when the token was issued
+	TokenID   string // jti
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// IsAdmin reports whether the caller administers their organisation.
+func (p Principal) IsAdmin() bool {
+	return p.Role == "admin"
+}
+
+// principalFromClaims rejects tokens without a user or an organisation,
+// such as those issued before organisations existed.
+func principalFromClaims(c *Claims) (Principal, error) {
+	userID, err := primitive.ObjectIDFromHex(c.UserID)
+	if err != nil || userID.IsZero() {
+		return Principal{}, errIncompleteClaims
+	}
+	tenantID, err := primitive.ObjectIDFromHex(c.TenantID)
+	if err != nil || tenantID.IsZero() || c.Username == "" {
+		return Principal{}, errIncompleteClaims
+	}
+	p := Principal{UserID: userID, TenantID: tenantID, Username: c.Username, Role: c.Role, Version: c.Version, TokenID: c.ID}
+	if c.IssuedAt != nil {
+		p.IssuedAt = c.IssuedAt.Time
+	}
+	if c.ExpiresAt != nil {
+		p.ExpiresAt = c.ExpiresAt.Time
+	}
+	return p, nil
+}
+
+// SetPrincipal stores the caller of a request; AuthMiddleware does this for
+// every request it lets through.
+func SetPrincipal(c *gin.Context, p Principal) {
+	c.Set(principalKey, p)
+}
+
+// PrincipalFrom returns the caller stored by SetPrincipal.
+func PrincipalFrom(c *gin.Context) (Principal, bool) {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return Principal{}, false
+	}
+	p, ok := value.(Principal)
+	return p, ok
+}
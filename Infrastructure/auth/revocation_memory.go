@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryRevocationStore keeps revocations in process memory. It suits a
+// single instance; revocations are lost on restart.
+type MemoryRevocationStore struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	tokens map[string]time.Time // jti → expiry
+	users  map[string]time.Time // user id → tokens issued up to this second are revoked
+}
+
+// NewMemoryRevocationStore keeps per-user revocations for ttl, which must be
+// at least the lifetime of an access token.
+func NewMemoryRevocationStore(ttl time.Duration) *MemoryRevocationStore {
+	return &MemoryRevocationStore{ttl: ttl, tokens: map[string]time.Time{}, users: map[string]time.Time{}}
+}
+
+func (s *MemoryRevocationStore) Revoke(_ context.Context, jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune(time.Now())
+	s.tokens[jti] = expiresAt
+	return nil
+}
+
+func (s *MemoryRevocationStore) RevokeUser(_ context.Context, userID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune(time.Now())
+	at = at.Truncate(time.Second)
+	if at.After(s.users[userID]) {
+		s.users[userID] = at
+	}
+	return nil
+}
+
+func (s *MemoryRevocationStore) IsRevoked(_ context.Context, jti, userID string, issuedAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tokens[jti]; ok && jti != "" {
+		return true, nil
+	}
+	until, ok := s.users[userID]
+	return ok && !issuedAt.Truncate(time.Second).After(until), nil
+}
+
+// prune drops entries that no longer block any unexpired token.
+func (s *MemoryRevocationStore) prune(now time.Time) {
+	for jti, exp := range s.tokens {
+		if now.After(exp) {
+			delete(s.tokens, jti)
+		}
+	}
+	for id, at := range s.users {
+		if now.After(at.Add(s.ttl)) {
+			delete(s.users, id)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoRevocationStore keeps revocations in a collection whose TTL index
+// removes them once the tokens they block have expired.
+type MongoRevocationStore struct {
+	coll    *mongo.Collection
+	ttl     time.Duration
+	timeout time.Duration
+}
+
+// NewMongoRevocationStore keeps per-user revocations for ttl, which must be
+// at least the lifetime of an access token.
+func NewMongoRevocationStore(coll *mongo.Collection, ttl time.Duration) *MongoRevocationStore {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, _ = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return &MongoRevocationStore{coll: coll, ttl: ttl, timeout: 5 * time.Second}
+}
+
+func (s *MongoRevocationStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	_, err := s.coll.UpdateOne(ctx,
+		bson.M{"_id": "jti:" + jti},
+		bson.M{"$set": bson.M{"expires_at": expiresAt}},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+func (s *MongoRevocationStore) RevokeUser(ctx context.Context, userID string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	at = at.Truncate(time.Second)
+	_, err := s.coll.UpdateOne(ctx,
+		bson.M{"_id": "user:" + userID},
+		bson.M{"$max": bson.M{"revoked_before": at, "expires_at": at.Add(s.ttl)}},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+func (s *MongoRevocationStore) IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	// revoked_before holds the last revoked second, which is itself revoked
+	or := bson.A{bson.M{"_id": "user:" + userID, "revoked_before": bson.M{"$gte": issuedAt.Truncate(time.Second)}}}
+	if jti != "" {
+		or = append(or, bson.M{"_id": "jti:" + jti})
+	}
+	n, err := s.coll.CountDocuments(ctx, bson.M{"$or": or}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// RevocationStore remembers access tokens that were invalidated before they
+// expired. Entries only need to outlive the tokens they block.
+type RevocationStore interface {
+	// Revoke blocks the token with the given jti until it expires.
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	// RevokeUser blocks every token of userID issued up to at. Token issue
+	// times only have second precision, so every token stamped with the
+	// second of at is blocked too, including one issued just after it; a
+	// user signing in again within that second has to retry a second later.
+	RevokeUser(ctx context.Context, userID string, at time.Time) error
+	IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error)
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"task_manager1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultUserCacheTTL bounds how long AuthMiddleware can miss a role change
+// or a disabled account.
+const DefaultUserCacheTTL = 5 * time.Second
+
+// UserLookup loads the current state of the user a token was issued to.
+type UserLookup interface {
+	FindByID(ctx context.Context, id primitive.ObjectID) (Domain.User, error)
+}
+
+type cachedUser struct {
+	user    Domain.User
+	fetched time.Time
+}
+
+// userCache keeps user records for a short while so that checking them does
+// not cost a database round trip on every request.
+type userCache struct {
+	users   UserLookup
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[primitive.ObjectID]cachedUser
+	pruned  time.Time // when expired entries were last dropped
+}
+
+func newUserCache(users UserLookup) *userCache {
+	return &userCache{users: users, ttl: DefaultUserCacheTTL, entries: map[primitive.ObjectID]cachedUser{}}
+}
+
+// get returns the user of p, or an empty user when it no longer exists.
+func (c *userCache) get(ctx context.Context, p Principal) (Domain.User, error) {
+	now := time.Now()
+	c.mu.Lock()
+	e, ok := c.entries[p.UserID]
+	ttl := c.ttl
+	c.mu.Unlock()
+	if ok && now.Sub(e.fetched) < ttl {
+		return e.user, nil
+	}
+
+	u, err := c.users.FindByID(Domain.WithTenant(ctx, p.TenantID), p.UserID)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// sweeping at most once per ttl keeps misses cheap while bounding the
+	// cache to the users seen in the last two periods
+	if now.Sub(c.pruned) >= c.ttl {
+		for id, e := range c.entries {
+			if now.Sub(e.fetched) >= c.ttl {
+				delete(c.entries, id)
+			}
+		}
+		c.pruned = now
+	}
+	c.entries[p.UserID] = cachedUser{user: u, fetched: now}
+	return u, nil
+}
+
+func (c *userCache) setTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = ttl
+}
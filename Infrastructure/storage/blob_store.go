@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrBlobNotFound is returned by BlobStore.Open for unknown keys.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps the contents of uploaded files. Keys are chosen by the
+// caller and are made of letters and digits only.
+type BlobStore interface {
+	// Put stores everything read from r under key and returns its size.
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes a blob; deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+var errInvalidKey = errors.New("invalid blob key")
+
+func validKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GridFSBlobStore keeps blobs in a MongoDB GridFS bucket, using the key as
+// the file id.
+type GridFSBlobStore struct {
+	bucket  *gridfs.Bucket
+	timeout time.Duration
+}
+
+func NewGridFSBlobStore(db *mongo.Database, bucketName string) (*GridFSBlobStore, error) {
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(bucketName))
+	if err != nil {
+		return nil, err
+	}
+	return &GridFSBlobStore{bucket: bucket, timeout: 5 * time.Minute}, nil
+}
+
+// deadline is the I/O deadline for one blob transfer.
+func (s *GridFSBlobStore) deadline(ctx context.Context) time.Time {
+	if d, ok := ctx.Deadline(); ok {
+		return d
+	}
+	return time.Now().Add(s.timeout)
+}
+
+// Put streams r into the bucket chunk by chunk; the bucket's own upload
+// helpers share one buffer and are not safe for concurrent uploads.
+func (s *GridFSBlobStore) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	if !validKey(key) {
+		return 0, errInvalidKey
+	}
+	us, err := s.bucket.OpenUploadStreamWithID(key, key)
+	if err != nil {
+		return 0, err
+	}
+	if err := us.SetWriteDeadline(s.deadline(ctx)); err != nil {
+		_ = us.Abort()
+		return 0, err
+	}
+	n, err := io.Copy(us, r)
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		_ = us.Abort()
+		return 0, err
+	}
+	return n, us.Close()
+}
+
+func (s *GridFSBlobStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	ds, err := s.bucket.OpenDownloadStream(key)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := ds.SetReadDeadline(s.deadline(ctx)); err != nil {
+		_ = ds.Close()
+		return nil, err
+	}
+	return ds, nil
+}
+
+func (s *GridFSBlobStore) Delete(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	err := s.bucket.DeleteContext(ctx, key)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,157 @@
+package Repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"task_manager1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CommentRepository stores the comment threads of tasks
+type CommentRepository interface {
+	ListByTask(ctx context.Context, taskID primitive.ObjectID) ([]Domain.Comment, error)
+	FindByID(ctx context.Context, hexID string) (Domain.Comment, error)
+	Create(ctx context.Context, c Domain.Comment) (Domain.Comment, error)
+	UpdateBody(ctx context.Context, id primitive.ObjectID, body string, mentions []string) (Domain.Comment, error)
+	Delete(ctx context.Context, id primitive.ObjectID) (bool, error)
+	DeleteByTasks(ctx context.Context, taskIDs []primitive.ObjectID) (int64, error)
+}
+
+type mongoCommentRepository struct {
+	coll    *mongo.Collection
+	timeout time.Duration
+}
+
+func NewMongoCommentRepository(coll *mongo.Collection) CommentRepository {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, _ = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+	return &mongoCommentRepository{coll: coll, timeout: 5 * time.Second}
+}
+
+// ListByTask returns the comments of a task, oldest first.
+func (r *mongoCommentRepository) ListByTask(ctx context.Context, taskID primitive.ObjectID) ([]Domain.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	filter, err := scoped(ctx, bson.M{"task_id": taskID})
+	if err != nil {
+		return nil, err
+	}
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	comments := []Domain.Comment{}
+	if err := cur.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (r *mongoCommentRepository) FindByID(ctx context.Context, hexID string) (Domain.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	oid, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		return Domain.Comment{}, errors.New("invalid id")
+	}
+	filter, err := scoped(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return Domain.Comment{}, err
+	}
+	var c Domain.Comment
+	if err := r.coll.FindOne(ctx, filter).Decode(&c); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Domain.Comment{}, nil
+		}
+		return Domain.Comment{}, err
+	}
+	return c, nil
+}
+
+func (r *mongoCommentRepository) Create(ctx context.Context, c Domain.Comment) (Domain.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	tenant, err := tenantFor(ctx, c.TenantID)
+	if err != nil {
+		return Domain.Comment{}, err
+	}
+	c.TenantID = tenant
+	c.CreatedAt = time.Now().UTC().Truncate(time.Millisecond)
+	res, err := r.coll.InsertOne(ctx, c)
+	if err != nil {
+		return Domain.Comment{}, err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		c.ID = oid
+	}
+	return c, nil
+}
+
+// UpdateBody replaces the body and mentions of a comment and marks it as
+// edited. An empty comment means it does not exist.
+func (r *mongoCommentRepository) UpdateBody(ctx context.Context, id primitive.ObjectID, body string, mentions []string) (Domain.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	set := bson.M{"body": body, "edited_at": time.Now().UTC().Truncate(time.Millisecond)}
+	update := bson.M{"$set": set}
+	if len(mentions) > 0 {
+		set["mentions"] = mentions
+	} else {
+		update["$unset"] = bson.M{"mentions": ""}
+	}
+	filter, err := scoped(ctx, bson.M{"_id": id})
+	if err != nil {
+		return Domain.Comment{}, err
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var c Domain.Comment
+	if err := r.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&c); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Domain.Comment{}, nil
+		}
+		return Domain.Comment{}, err
+	}
+	return c, nil
+}
+
+func (r *mongoCommentRepository) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	filter, err := scoped(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	res, err := r.coll.DeleteOne(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+// DeleteByTasks removes the threads of tasks that no longer exist.
+func (r *mongoCommentRepository) DeleteByTasks(ctx context.Context, taskIDs []primitive.ObjectID) (int64, error) {
+	if len(taskIDs) == 0 {
+		return 0, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	filter, err := scoped(ctx, bson.M{"task_id": bson.M{"$in": taskIDs}})
+	if err != nil {
+		return 0, err
+	}
+	res, err := r.coll.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
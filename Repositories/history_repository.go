@@ -0,0 +1,96 @@
+package Repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"task_manager1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// HistoryRepository stores the change log of tasks
+type HistoryRepository interface {
+	Record(ctx context.Context, e Domain.TaskHistoryEntry) error
+	ListByTask(ctx context.Context, hexTaskID string) ([]Domain.TaskHistoryEntry, error)
+	AsOf(ctx context.Context, hexTaskID string, at time.Time) (Domain.TaskHistoryEntry, error)
+}
+
+type mongoHistoryRepository struct {
+	coll    *mongo.Collection
+	timeout time.Duration
+}
+
+func NewMongoHistoryRepository(coll *mongo.Collection) HistoryRepository {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, _ = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "at", Value: 1}},
+	})
+	return &mongoHistoryRepository{coll: coll, timeout: 5 * time.Second}
+}
+
+func (r *mongoHistoryRepository) Record(ctx context.Context, e Domain.TaskHistoryEntry) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	tenant, err := tenantFor(ctx, e.TenantID)
+	if err != nil {
+		return err
+	}
+	e.TenantID = tenant
+	_, err = r.coll.InsertOne(ctx, e)
+	return err
+}
+
+func (r *mongoHistoryRepository) ListByTask(ctx context.Context, hexTaskID string) ([]Domain.TaskHistoryEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	oid, err := primitive.ObjectIDFromHex(hexTaskID)
+	if err != nil {
+		return nil, errors.New("invalid id")
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: 1}, {Key: "_id", Value: 1}})
+	filter, err := scoped(ctx, bson.M{"task_id": oid})
+	if err != nil {
+		return nil, err
+	}
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	entries := []Domain.TaskHistoryEntry{}
+	if err := cur.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// AsOf returns the latest entry recorded at or before at, or an empty entry
+// when the task did not exist yet.
+func (r *mongoHistoryRepository) AsOf(ctx context.Context, hexTaskID string, at time.Time) (Domain.TaskHistoryEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	oid, err := primitive.ObjectIDFromHex(hexTaskID)
+	if err != nil {
+		return Domain.TaskHistoryEntry{}, errors.New("invalid id")
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "at", Value: -1}, {Key: "_id", Value: -1}})
+	filter, err := scoped(ctx, bson.M{"task_id": oid, "at": bson.M{"$lte": at}})
+	if err != nil {
+		return Domain.TaskHistoryEntry{}, err
+	}
+	var e Domain.TaskHistoryEntry
+	if err := r.coll.FindOne(ctx, filter, opts).Decode(&e); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Domain.TaskHistoryEntry{}, nil
+		}
+		return Domain.TaskHistoryEntry{}, err
+	}
+	return e, nil
+}
@@ -0,0 +1,198 @@
+package Repositories
+
+import (
+	"context"
+	"time"
+
+	"task_manager1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// legacyDueDateLayouts are the string formats accepted before due dates were
+// stored as BSON dates. Values without a zone, and date-only values, which
+// are taken as the end of that day, are read in the owner's time zone.
+var legacyDueDateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"}
+
+// MigrateDueDates converts task documents whose due_date is still a string
+// into BSON dates. Values that cannot be parsed are moved to due_date_legacy
+// so nothing is lost and the task keeps decoding. It is safe to run repeatedly.
+func MigrateDueDates(ctx context.Context, coll, users *mongo.Collection) (migrated, unparsed int, err error) {
+	cur, err := coll.Find(ctx, bson.M{"due_date": bson.M{"$type": "string"}})
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cur.Close(ctx)
+
+	zones := ownerZones{users: users, cache: map[primitive.ObjectID]*time.Location{}}
+	for cur.Next(ctx) {
+		var doc struct {
+			ID      primitive.ObjectID `bson:"_id"`
+			OwnerID primitive.ObjectID `bson:"owner_id"`
+			DueDate string             `bson:"due_date"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			return migrated, unparsed, err
+		}
+		loc, err := zones.of(ctx, doc.OwnerID)
+		if err != nil {
+			return migrated, unparsed, err
+		}
+		update := bson.M{
+			"$set":   bson.M{"due_date_legacy": doc.DueDate},
+			"$unset": bson.M{"due_date": ""},
+		}
+		if due, ok := parseLegacyDueDate(doc.DueDate, loc); ok {
+			update = bson.M{"$set": bson.M{"due_date": due}}
+			migrated++
+		} else {
+			unparsed++
+		}
+		if _, err := coll.UpdateOne(ctx, bson.M{"_id": doc.ID, "due_date": doc.DueDate}, update); err != nil {
+			return migrated, unparsed, err
+		}
+	}
+	return migrated, unparsed, cur.Err()
+}
+
+func parseLegacyDueDate(value string, loc *time.Location) (time.Time, bool) {
+	for _, layout := range legacyDueDateLayouts {
+		ts, err := time.ParseInLocation(layout, value, loc)
+		if err != nil {
+			continue
+		}
+		if layout == "2006-01-02" {
+			ts = time.Date(ts.Year(), ts.Month(), ts.Day(), 23, 59, 59, 0, loc)
+		}
+		return ts.UTC(), true
+	}
+	return time.Time{}, false
+}
+
+// ownerZones resolves and caches the time zone of task owners. Owners that
+// no longer exist, or have no valid zone, read dates in UTC.
+type ownerZones struct {
+	users *mongo.Collection
+	cache map[primitive.ObjectID]*time.Location
+}
+
+func (z ownerZones) of(ctx context.Context, owner primitive.ObjectID) (*time.Location, error) {
+	if loc, ok := z.cache[owner]; ok {
+		return loc, nil
+	}
+	loc := time.UTC
+	if !owner.IsZero() {
+		var u Domain.User
+		err := z.users.FindOne(ctx, bson.M{"_id": owner}).Decode(&u)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+		if u.Timezone != "" {
+			if l, err := time.LoadLocation(u.Timezone); err == nil {
+				loc = l
+			}
+		}
+	}
+	z.cache[owner] = loc
+	return loc, nil
+}
+
+// BackfillTaskTimestamps gives tasks created before timestamps were tracked a
+// created_at (and, if missing, updated_at) taken from their ObjectID.
+func BackfillTaskTimestamps(ctx context.Context, coll *mongo.Collection) (int64, error) {
+	res, err := coll.UpdateMany(ctx, bson.M{"created_at": bson.M{"$exists": false}}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"created_at": bson.M{"$toDate": "$_id"},
+			"updated_at": bson.M{"$ifNull": bson.A{"$updated_at", bson.M{"$toDate": "$_id"}}},
+		}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// BackfillTaskPriorities gives tasks created before priorities existed the
+// default priority and its rank.
+func BackfillTaskPriorities(ctx context.Context, coll *mongo.Collection) (int64, error) {
+	res, err := coll.UpdateMany(ctx, bson.M{"priority": bson.M{"$exists": false}}, bson.M{"$set": bson.M{
+		"priority":      Domain.DefaultPriority,
+		"priority_rank": Domain.PriorityRank(Domain.DefaultPriority),
+	}})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// BackfillTaskProjects moves tasks created before projects existed into
+// their owner's personal project. Tasks without an owner are left to admins.
+func BackfillTaskProjects(ctx context.Context, tasks, users *mongo.Collection, projects ProjectRepository) (int64, error) {
+	legacy := bson.M{"project_id": bson.M{"$exists": false}, "owner_id": bson.M{"$exists": true}}
+	owners, err := tasks.Distinct(ctx, "owner_id", legacy)
+	if err != nil {
+		return 0, err
+	}
+	var moved int64
+	for _, value := range owners {
+		ownerID, ok := value.(primitive.ObjectID)
+		if !ok {
+			continue
+		}
+		var u Domain.User
+		if err := users.FindOne(ctx, bson.M{"_id": ownerID}).Decode(&u); err != nil && err != mongo.ErrNoDocuments {
+			return moved, err
+		}
+		// the personal project lives in the organisation of the owner's tasks
+		var task Domain.Task
+		if err := tasks.FindOne(ctx, bson.M{"owner_id": ownerID}).Decode(&task); err != nil {
+			return moved, err
+		}
+		scope := Domain.WithTenant(ctx, task.TenantID)
+		p, err := projects.EnsurePersonal(scope, Domain.ProjectMember{UserID: ownerID, Username: u.Username})
+		if err != nil {
+			return moved, err
+		}
+		res, err := tasks.UpdateMany(ctx,
+			bson.M{"owner_id": ownerID, "project_id": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"project_id": p.ID}})
+		if err != nil {
+			return moved, err
+		}
+		moved += res.ModifiedCount
+	}
+	return moved, nil
+}
+
+// BackfillTenants moves documents stored before organisations existed into
+// the default organisation, created named name on first need. Every run uses
+// the same organisation, so a run that fails part way can simply be repeated.
+// Run it before the other backfills so every document they touch has an
+// organisation.
+func BackfillTenants(ctx context.Context, orgs OrganizationRepository, name string, colls ...*mongo.Collection) (int64, error) {
+	legacy := bson.M{"tenant_id": bson.M{"$exists": false}}
+	var org Domain.Organization
+	var moved int64
+	for _, coll := range colls {
+		n, err := coll.CountDocuments(ctx, legacy)
+		if err != nil {
+			return moved, err
+		}
+		if n == 0 {
+			continue
+		}
+		if org.ID.IsZero() {
+			if org, err = orgs.EnsureDefault(ctx, name); err != nil {
+				return moved, err
+			}
+		}
+		res, err := coll.UpdateMany(ctx, legacy, bson.M{"$set": bson.M{"tenant_id": org.ID}})
+		if err != nil {
+			return moved, err
+		}
+		moved += res.ModifiedCount
+	}
+	return moved, nil
+}
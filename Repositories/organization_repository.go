@@ -0,0 +1,110 @@
+package Repositories
+
+import (
+	"context"
+	"time"
+
+	"task_manager1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// OrganizationRepository stores the organisations (tenants) of the deployment
+type OrganizationRepository interface {
+	Create(ctx context.Context, o Domain.Organization) (Domain.Organization, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (Domain.Organization, error)
+	EnsureDefault(ctx context.Context, name string) (Domain.Organization, error)
+	Delete(ctx context.Context, id primitive.ObjectID) (bool, error)
+}
+
+type mongoOrganizationRepository struct {
+	coll    *mongo.Collection
+	timeout time.Duration
+}
+
+func NewMongoOrganizationRepository(coll *mongo.Collection) OrganizationRepository {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, _ = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		// one default organisation per deployment
+		Keys: bson.D{{Key: "default", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"default": true}),
+	})
+	return &mongoOrganizationRepository{coll: coll, timeout: 5 * time.Second}
+}
+
+func (r *mongoOrganizationRepository) Create(ctx context.Context, o Domain.Organization) (Domain.Organization, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	o.CreatedAt = time.Now().UTC().Truncate(time.Millisecond)
+	res, err := r.coll.InsertOne(ctx, o)
+	if err != nil {
+		return Domain.Organization{}, err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		o.ID = oid
+	}
+	return o, nil
+}
+
+// FindByID returns an organisation, or an empty one when it does not exist or
+// ctx is scoped to another organisation.
+func (r *mongoOrganizationRepository) FindByID(ctx context.Context, id primitive.ObjectID) (Domain.Organization, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	filter, err := scoped(ctx, bson.M{"_id": id})
+	if err != nil {
+		return Domain.Organization{}, err
+	}
+	// an organisation is its own tenant
+	if tenant, ok := filter["tenant_id"]; ok {
+		delete(filter, "tenant_id")
+		if tenant != id {
+			return Domain.Organization{}, nil
+		}
+	}
+	var o Domain.Organization
+	if err := r.coll.FindOne(ctx, filter).Decode(&o); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Domain.Organization{}, nil
+		}
+		return Domain.Organization{}, err
+	}
+	return o, nil
+}
+
+// EnsureDefault returns the default organisation, creating it named name on
+// first use. Later calls return the same organisation whatever name they pass.
+func (r *mongoOrganizationRepository) EnsureDefault(ctx context.Context, name string) (Domain.Organization, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	filter := bson.M{"default": true}
+	update := bson.M{"$setOnInsert": bson.M{
+		"name":       name,
+		"created_at": time.Now().UTC().Truncate(time.Millisecond),
+	}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var o Domain.Organization
+	err := r.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&o)
+	if mongo.IsDuplicateKeyError(err) {
+		// lost the race to create it
+		err = r.coll.FindOne(ctx, filter).Decode(&o)
+	}
+	return o, err
+}
+
+// Delete removes an organisation record. It does not touch its documents; it
+// exists to undo a registration that failed half way.
+func (r *mongoOrganizationRepository) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	res, err := r.coll.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
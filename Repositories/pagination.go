@@ -0,0 +1,113 @@
+package Repositories
+
+import (
+	"encoding/base64"
+
+	"task_manager1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// sortKey is one field of a keyset sort. Every ordering ends with an _id key
+// so that each position in it is unique.
+type sortKey struct {
+	field string
+	desc  bool
+	value func(t Domain.Task) interface{}
+}
+
+// pageCursor is the decoded form of the opaque cursor handed to clients.
+// It is BSON encoded so that dates and ObjectIDs keep their types.
+type pageCursor struct {
+	Sort   string `bson:"s"`
+	Values bson.A `bson:"v"`
+}
+
+func encodeCursor(c pageCursor) (string, error) {
+	raw, err := bson.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeCursor(token string) (pageCursor, error) {
+	var c pageCursor
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, Domain.ErrInvalidCursor
+	}
+	if err := bson.Unmarshal(raw, &c); err != nil || len(c.Values) == 0 {
+		return pageCursor{}, Domain.ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// keysetAfter builds the condition selecting documents strictly after the
+// cursor position for the given sort keys. Missing values sort before any
+// other value, mirroring MongoDB's own ordering of null.
+func keysetAfter(keys []sortKey, cur pageCursor) (bson.M, error) {
+	if len(cur.Values) != len(keys) {
+		return nil, Domain.ErrInvalidCursor
+	}
+	branches := bson.A{}
+	for i, k := range keys {
+		branch := bson.M{}
+		for j := 0; j < i; j++ {
+			branch[keys[j].field] = cur.Values[j]
+		}
+		cond := afterValue(k.desc, cur.Values[i])
+		if cond == nil {
+			continue
+		}
+		branch[k.field] = cond
+		branches = append(branches, branch)
+	}
+	if len(branches) == 0 {
+		return nil, Domain.ErrInvalidCursor
+	}
+	return bson.M{"$or": branches}, nil
+}
+
+// afterValue returns the condition for "strictly after v" on a single field,
+// or nil when nothing can follow v in that direction.
+func afterValue(desc bool, v interface{}) interface{} {
+	if v == nil {
+		if desc {
+			return nil
+		}
+		return bson.M{"$ne": nil}
+	}
+	if desc {
+		// nulls come last in descending order
+		return bson.M{"$not": bson.M{"$gte": v}}
+	}
+	return bson.M{"$gt": v}
+}
+
+// sortDoc builds the Mongo sort document for the keys.
+func sortDoc(keys []sortKey) bson.D {
+	doc := bson.D{}
+	for _, k := range keys {
+		dir := 1
+		if k.desc {
+			dir = -1
+		}
+		doc = append(doc, bson.E{Key: k.field, Value: dir})
+	}
+	return doc
+}
+
+// idKey is the _id tie-breaker that terminates every ordering.
+func idKey(desc bool) sortKey {
+	return sortKey{field: "_id", desc: desc, value: func(t Domain.Task) interface{} { return t.ID }}
+}
+
+// cursorFor captures the position of t in the ordering described by keys.
+func cursorFor(sortName string, keys []sortKey, t Domain.Task) (string, error) {
+	c := pageCursor{Sort: sortName, Values: bson.A{}}
+	for _, k := range keys {
+		c.Values = append(c.Values, k.value(t))
+	}
+	return encodeCursor(c)
+}
@@ -0,0 +1,263 @@
+package Repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"task_manager1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ProjectRepository stores projects and their members
+type ProjectRepository interface {
+	List(ctx context.Context) ([]Domain.Project, error)
+	ListByMember(ctx context.Context, userID primitive.ObjectID) ([]Domain.Project, error)
+	FindByID(ctx context.Context, hexID string) (Domain.Project, error)
+	Create(ctx context.Context, p Domain.Project) (Domain.Project, error)
+	Update(ctx context.Context, p Domain.Project) (Domain.Project, error)
+	SetMember(ctx context.Context, id primitive.ObjectID, m Domain.ProjectMember) (Domain.Project, error)
+	RemoveMember(ctx context.Context, id, userID primitive.ObjectID) (Domain.Project, error)
+	EnsurePersonal(ctx context.Context, owner Domain.ProjectMember) (Domain.Project, error)
+	Delete(ctx context.Context, id primitive.ObjectID) (bool, error)
+}
+
+type mongoProjectRepository struct {
+	coll    *mongo.Collection
+	timeout time.Duration
+}
+
+func NewMongoProjectRepository(coll *mongo.Collection) ProjectRepository {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, _ = coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "members.user_id", Value: 1}}},
+		{
+			// one personal project per user
+			Keys: bson.D{{Key: "personal_of", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"personal_of": bson.M{"$exists": true}}),
+		},
+	})
+	return &mongoProjectRepository{coll: coll, timeout: 5 * time.Second}
+}
+
+func (r *mongoProjectRepository) find(ctx context.Context, filter bson.M) ([]Domain.Project, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	filter, err := scoped(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := r.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	projects := []Domain.Project{}
+	if err := cur.All(ctx, &projects); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// List returns every project of the organisation by name.
+func (r *mongoProjectRepository) List(ctx context.Context) ([]Domain.Project, error) {
+	return r.find(ctx, bson.M{})
+}
+
+// ListByMember returns the projects userID is a member of, by name.
+func (r *mongoProjectRepository) ListByMember(ctx context.Context, userID primitive.ObjectID) ([]Domain.Project, error) {
+	return r.find(ctx, bson.M{"members.user_id": userID})
+}
+
+func (r *mongoProjectRepository) FindByID(ctx context.Context, hexID string) (Domain.Project, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	oid, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		return Domain.Project{}, errors.New("invalid id")
+	}
+	return r.findOne(ctx, oid)
+}
+
+func (r *mongoProjectRepository) findOne(ctx context.Context, id primitive.ObjectID) (Domain.Project, error) {
+	filter, err := scoped(ctx, bson.M{"_id": id})
+	if err != nil {
+		return Domain.Project{}, err
+	}
+	var p Domain.Project
+	if err := r.coll.FindOne(ctx, filter).Decode(&p); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Domain.Project{}, nil
+		}
+		return Domain.Project{}, err
+	}
+	return p, nil
+}
+
+func (r *mongoProjectRepository) Create(ctx context.Context, p Domain.Project) (Domain.Project, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	tenant, err := tenantFor(ctx, p.TenantID)
+	if err != nil {
+		return Domain.Project{}, err
+	}
+	p.TenantID = tenant
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	p.CreatedAt, p.UpdatedAt = now, now
+	res, err := r.coll.InsertOne(ctx, p)
+	if err != nil {
+		return Domain.Project{}, err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		p.ID = oid
+	}
+	return p, nil
+}
+
+// Update replaces the name and description of a project. An empty project
+// means it does not exist.
+func (r *mongoProjectRepository) Update(ctx context.Context, p Domain.Project) (Domain.Project, error) {
+	set := bson.M{"name": p.Name}
+	update := bson.M{"$set": set}
+	if p.Description == "" {
+		update["$unset"] = bson.M{"description": ""}
+	} else {
+		set["description"] = p.Description
+	}
+	return r.update(ctx, bson.M{"_id": p.ID}, update)
+}
+
+// SetMember adds m to the project or, when the user is a member already,
+// changes their role. Demoting the last owner fails with
+// ErrLastProjectOwner; the check is part of the write.
+func (r *mongoProjectRepository) SetMember(ctx context.Context, id primitive.ObjectID, m Domain.ProjectMember) (Domain.Project, error) {
+	p, err := r.setRole(ctx, id, m)
+	if err != nil || !p.ID.IsZero() {
+		return p, err
+	}
+	p, err = r.update(ctx, bson.M{"_id": id, "members.user_id": bson.M{"$ne": m.UserID}}, bson.M{"$push": bson.M{"members": m}})
+	if err != nil || !p.ID.IsZero() {
+		return p, err
+	}
+	// the user was added concurrently; retry the role change once
+	if p, err = r.setRole(ctx, id, m); err != nil || !p.ID.IsZero() {
+		return p, err
+	}
+	return Domain.Project{}, r.ownerMiss(ctx, id, m.UserID)
+}
+
+// setRole changes the role of an existing member, keeping another owner when
+// the member is demoted.
+func (r *mongoProjectRepository) setRole(ctx context.Context, id primitive.ObjectID, m Domain.ProjectMember) (Domain.Project, error) {
+	filter := bson.M{"_id": id, "members.user_id": m.UserID}
+	if m.Role != Domain.ProjectOwner {
+		filter["members"] = otherOwner(m.UserID)
+	}
+	opts := options.FindOneAndUpdate().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"m.user_id": m.UserID}}})
+	return r.update(ctx, filter, bson.M{"$set": bson.M{"members.$[m].role": m.Role}}, opts)
+}
+
+// RemoveMember takes userID out of the project. Removing the last owner
+// fails with ErrLastProjectOwner; the check is part of the write.
+func (r *mongoProjectRepository) RemoveMember(ctx context.Context, id, userID primitive.ObjectID) (Domain.Project, error) {
+	p, err := r.update(ctx, bson.M{"_id": id, "members": otherOwner(userID)}, bson.M{"$pull": bson.M{"members": bson.M{"user_id": userID}}})
+	if err != nil || !p.ID.IsZero() {
+		return p, err
+	}
+	return Domain.Project{}, r.ownerMiss(ctx, id, userID)
+}
+
+// otherOwner matches projects with an owner besides userID. Every project has
+// an owner, so it only rules out demoting or removing the last one.
+func otherOwner(userID primitive.ObjectID) bson.M {
+	return bson.M{"$elemMatch": bson.M{"role": Domain.ProjectOwner, "user_id": bson.M{"$ne": userID}}}
+}
+
+// ownerMiss explains a member change that matched nothing: ErrLastProjectOwner
+// when userID is the project's only owner, otherwise the project is gone.
+func (r *mongoProjectRepository) ownerMiss(ctx context.Context, id, userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	p, err := r.findOne(ctx, id)
+	if err != nil {
+		return err
+	}
+	if p.RoleOf(userID) == Domain.ProjectOwner && p.Owners() == 1 {
+		return Domain.ErrLastProjectOwner
+	}
+	return nil
+}
+
+// update applies update to the project matching filter, stamping
+// updated_at, and returns the result or an empty project when nothing matched.
+func (r *mongoProjectRepository) update(ctx context.Context, filter, update bson.M, opts ...*options.FindOneAndUpdateOptions) (Domain.Project, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	filter, err := scoped(ctx, filter)
+	if err != nil {
+		return Domain.Project{}, err
+	}
+	set, _ := update["$set"].(bson.M)
+	if set == nil {
+		set = bson.M{}
+		update["$set"] = set
+	}
+	set["updated_at"] = time.Now().UTC().Truncate(time.Millisecond)
+	opts = append(opts, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	var result Domain.Project
+	if err := r.coll.FindOneAndUpdate(ctx, filter, update, opts...).Decode(&result); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Domain.Project{}, nil
+		}
+		return Domain.Project{}, err
+	}
+	return result, nil
+}
+
+// EnsurePersonal returns the personal project of owner, creating it in the
+// organisation of ctx with owner as its only member on first use.
+func (r *mongoProjectRepository) EnsurePersonal(ctx context.Context, owner Domain.ProjectMember) (Domain.Project, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	tenant, err := tenantFor(ctx, primitive.NilObjectID)
+	if err != nil {
+		return Domain.Project{}, err
+	}
+	filter := bson.M{"personal_of": owner.UserID, "tenant_id": tenant}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	owner.Role = Domain.ProjectOwner
+	update := bson.M{"$setOnInsert": bson.M{
+		"name":       Domain.PersonalProjectName,
+		"members":    []Domain.ProjectMember{owner},
+		"created_at": now,
+		"created_by": owner.Username,
+		"updated_at": now,
+	}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var p Domain.Project
+	err = r.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&p)
+	if mongo.IsDuplicateKeyError(err) {
+		// lost the race to create it
+		err = r.coll.FindOne(ctx, filter).Decode(&p)
+	}
+	return p, err
+}
+
+func (r *mongoProjectRepository) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	filter, err := scoped(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	res, err := r.coll.DeleteOne(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
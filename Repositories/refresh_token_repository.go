@@ -0,0 +1,122 @@
+package Repositories
+
+import (
+	"context"
+	"time"
+
+	"task_manager1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RefreshTokenRepository stores hashed refresh tokens
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, t Domain.RefreshToken) (Domain.RefreshToken, error)
+	FindByHash(ctx context.Context, hash string) (Domain.RefreshToken, error)
+	MarkUsed(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error)
+	RevokeFamily(ctx context.Context, familyID primitive.ObjectID, at time.Time) (int64, error)
+	RevokeUser(ctx context.Context, userID primitive.ObjectID, at time.Time) (int64, error)
+}
+
+type mongoRefreshTokenRepository struct {
+	coll    *mongo.Collection
+	timeout time.Duration
+}
+
+func NewMongoRefreshTokenRepository(coll *mongo.Collection) RefreshTokenRepository {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, _ = coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "family_id", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		// expired tokens are useless, let Mongo remove them
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return &mongoRefreshTokenRepository{coll: coll, timeout: 5 * time.Second}
+}
+
+func (r *mongoRefreshTokenRepository) Create(ctx context.Context, t Domain.RefreshToken) (Domain.RefreshToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	tenant, err := tenantFor(ctx, t.TenantID)
+	if err != nil {
+		return Domain.RefreshToken{}, err
+	}
+	t.TenantID = tenant
+	res, err := r.coll.InsertOne(ctx, t)
+	if err != nil {
+		return Domain.RefreshToken{}, err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		t.ID = oid
+	}
+	return t, nil
+}
+
+// FindByHash returns the token with the given hash, or an empty one.
+func (r *mongoRefreshTokenRepository) FindByHash(ctx context.Context, hash string) (Domain.RefreshToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	filter, err := scoped(ctx, bson.M{"hash": hash})
+	if err != nil {
+		return Domain.RefreshToken{}, err
+	}
+	var t Domain.RefreshToken
+	if err := r.coll.FindOne(ctx, filter).Decode(&t); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Domain.RefreshToken{}, nil
+		}
+		return Domain.RefreshToken{}, err
+	}
+	return t, nil
+}
+
+// MarkUsed records that a token was exchanged. It reports false when the
+// token was already used or revoked, so two concurrent refreshes cannot
+// both succeed.
+func (r *mongoRefreshTokenRepository) MarkUsed(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	filter, err := scoped(ctx, bson.M{
+		"_id":        id,
+		"used_at":    bson.M{"$exists": false},
+		"revoked_at": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return false, err
+	}
+	res, err := r.coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"used_at": at}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// RevokeFamily revokes every live token of a family and returns how many.
+func (r *mongoRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID primitive.ObjectID, at time.Time) (int64, error) {
+	return r.revoke(ctx, bson.M{"family_id": familyID}, at)
+}
+
+func (r *mongoRefreshTokenRepository) revoke(ctx context.Context, filter bson.M, at time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	filter["revoked_at"] = bson.M{"$exists": false}
+	filter, err := scoped(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	res, err := r.coll.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revoked_at": at}})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// RevokeUser revokes every live token of a user and returns how many.
+func (r *mongoRefreshTokenRepository) RevokeUser(ctx context.Context, userID primitive.ObjectID, at time.Time) (int64, error) {
+	return r.revoke(ctx, bson.M{"user_id": userID}, at)
+}
@@ -0,0 +1,167 @@
+package Repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"task_manager1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TagRepository stores the tag catalogue of each organisation
+type TagRepository interface {
+	List(ctx context.Context) ([]Domain.Tag, error)
+	FindByID(ctx context.Context, hexID string) (Domain.Tag, error)
+	FindByNames(ctx context.Context, names []string) ([]Domain.Tag, error)
+	Create(ctx context.Context, t Domain.Tag) (Domain.Tag, error)
+	Update(ctx context.Context, t Domain.Tag) (Domain.Tag, error)
+	Delete(ctx context.Context, id primitive.ObjectID) (bool, error)
+}
+
+type mongoTagRepository struct {
+	coll    *mongo.Collection
+	timeout time.Duration
+}
+
+func NewMongoTagRepository(coll *mongo.Collection) TagRepository {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// names were unique across the deployment before catalogues were per organisation
+	_, _ = coll.Indexes().DropOne(ctx, "name_1")
+	_, _ = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tenant_id", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return &mongoTagRepository{coll: coll, timeout: 5 * time.Second}
+}
+
+func (r *mongoTagRepository) List(ctx context.Context) ([]Domain.Tag, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	filter, err := scoped(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	cur, err := r.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	tags := []Domain.Tag{}
+	if err := cur.All(ctx, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (r *mongoTagRepository) FindByID(ctx context.Context, hexID string) (Domain.Tag, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	oid, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		return Domain.Tag{}, errors.New("invalid id")
+	}
+	filter, err := scoped(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return Domain.Tag{}, err
+	}
+	var t Domain.Tag
+	if err := r.coll.FindOne(ctx, filter).Decode(&t); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Domain.Tag{}, nil
+		}
+		return Domain.Tag{}, err
+	}
+	return t, nil
+}
+
+// FindByNames returns the catalogue entries for names; unknown names are skipped.
+func (r *mongoTagRepository) FindByNames(ctx context.Context, names []string) ([]Domain.Tag, error) {
+	if len(names) == 0 {
+		return []Domain.Tag{}, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	filter, err := scoped(ctx, bson.M{"name": bson.M{"$in": names}})
+	if err != nil {
+		return nil, err
+	}
+	cur, err := r.coll.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	tags := []Domain.Tag{}
+	if err := cur.All(ctx, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (r *mongoTagRepository) Create(ctx context.Context, t Domain.Tag) (Domain.Tag, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	tenant, err := tenantFor(ctx, t.TenantID)
+	if err != nil {
+		return Domain.Tag{}, err
+	}
+	t.TenantID = tenant
+	t.CreatedAt = time.Now().UTC().Truncate(time.Millisecond)
+	res, err := r.coll.InsertOne(ctx, t)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return Domain.Tag{}, Domain.ErrTagExists
+		}
+		return Domain.Tag{}, err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		t.ID = oid
+	}
+	return t, nil
+}
+
+// Update replaces the name and color of a tag. An empty tag means it does not exist.
+func (r *mongoTagRepository) Update(ctx context.Context, t Domain.Tag) (Domain.Tag, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	set := bson.M{"name": t.Name}
+	update := bson.M{"$set": set}
+	if t.Color == "" {
+		update["$unset"] = bson.M{"color": ""}
+	} else {
+		set["color"] = t.Color
+	}
+	filter, err := scoped(ctx, bson.M{"_id": t.ID})
+	if err != nil {
+		return Domain.Tag{}, err
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var result Domain.Tag
+	if err := r.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&result); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return Domain.Tag{}, nil
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			return Domain.Tag{}, Domain.ErrTagExists
+		}
+		return Domain.Tag{}, err
+	}
+	return result, nil
+}
+
+func (r *mongoTagRepository) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	filter, err := scoped(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	res, err := r.coll.DeleteOne(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
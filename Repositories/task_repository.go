@@ -3,6 +3,7 @@ package Repositories
 import (
 	"context"
 	"errors"
+	"regexp"
 	"time"
 
 	"task_manager1/Domain"
@@ -14,40 +15,268 @@ import (
 )
 
 type TaskRepository interface {
-	FindAll(ctx context.Context) ([]Domain.Task, error)
+	FindAll(ctx context.Context, f Domain.TaskFilter) (Domain.TaskPage, error)
 	FindByID(ctx context.Context, hexID string) (Domain.Task, error)
+	FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]Domain.Task, error)
+	FindBlockedBy(ctx context.Context, blockerIDs []primitive.ObjectID) ([]Domain.Task, error)
+	FindSubtasks(ctx context.Context, parentIDs []primitive.ObjectID) ([]Domain.Task, error)
 	Create(ctx context.Context, t Domain.Task) (Domain.Task, error)
-	Update(ctx context.Context, hexID string, t Domain.Task) (Domain.Task, error)
-	Delete(ctx context.Context, hexID string) (bool, error)
+	Update(ctx context.Context, hexID string, changes Domain.TaskChanges) (Domain.Task, error)
+	Purge(ctx context.Context, deletedBefore time.Time) ([]Domain.Task, error)
+	CountSubtasks(ctx context.Context, parentIDs []primitive.ObjectID) (map[primitive.ObjectID]Domain.SubtaskCounts, error)
+	FindRecurring(ctx context.Context, dueBefore time.Time, after primitive.ObjectID, limit int) ([]Domain.Task, error)
+	MarkNextCreated(ctx context.Context, id primitive.ObjectID) error
+	FindByTag(ctx context.Context, name string, limit int) ([]Domain.Task, error)
+	CountByProject(ctx context.Context, projectID primitive.ObjectID) (int64, error)
 }
 
+// purgeBatchSize caps how many trashed tasks Purge reads and removes at a time.
+const purgeBatchSize = 500
+
 type mongoTaskRepository struct {
 	coll    *mongo.Collection
 	timeout time.Duration
 }
 
 func NewMongoTaskRepository(coll *mongo.Collection) TaskRepository {
+	// index ownership lookups used by per-user listing
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, _ = coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "owner_id", Value: 1}}},
+		{Keys: bson.D{{Key: "shared_with", Value: 1}}},
+		{Keys: bson.D{{Key: "assignees", Value: 1}}},
+		{Keys: bson.D{{Key: "due_date", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "updated_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "priority_rank", Value: -1}, {Key: "due_date", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "blocked_by", Value: 1}}},
+		{Keys: bson.D{{Key: "tags", Value: 1}}},
+		{Keys: bson.D{{Key: "project_id", Value: 1}, {Key: "priority_rank", Value: -1}, {Key: "due_date", Value: 1}, {Key: "_id", Value: 1}}},
+		{
+			// one task per occurrence of a recurring series
+			Keys: bson.D{{Key: "recurrence.series_id", Value: 1}, {Key: "recurrence.index", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"recurrence.series_id": bson.M{"$exists": true}}),
+		},
+		{Keys: bson.D{{Key: "parent_id", Value: 1}, {Key: "created_at", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "deleted_at", Value: 1}, {Key: "_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+	})
 	return &mongoTaskRepository{coll: coll, timeout: 5 * time.Second}
 }
 
-func (r *mongoTaskRepository) FindAll(ctx context.Context) ([]Domain.Task, error) {
+// buildTaskFilter translates a Domain.TaskFilter into a Mongo query.
+func buildTaskFilter(f Domain.TaskFilter) bson.M {
+	conds := bson.A{}
+	if f.Trashed {
+		conds = append(conds, bson.M{"deleted_at": bson.M{"$ne": nil}})
+	} else {
+		conds = append(conds, bson.M{"deleted_at": nil})
+	}
+	if !f.VisibleTo.IsZero() {
+		visible := bson.A{
+			// owners keep access to tasks not yet moved into a project
+			bson.M{"owner_id": f.VisibleTo, "project_id": bson.M{"$exists": false}},
+			bson.M{"shared_with": f.VisibleTo},
+		}
+		if f.VisibleToName != "" {
+			visible = append(visible, bson.M{"assignees": f.VisibleToName})
+		}
+		if len(f.MemberOf) > 0 {
+			visible = append(visible, bson.M{"project_id": bson.M{"$in": f.MemberOf}})
+		}
+		conds = append(conds, bson.M{"$or": visible})
+	}
+	if !f.ProjectID.IsZero() {
+		conds = append(conds, bson.M{"project_id": f.ProjectID})
+	}
+	if !f.ParentID.IsZero() {
+		conds = append(conds, bson.M{"parent_id": f.ParentID})
+	}
+	if len(f.Tags) > 0 {
+		if f.TagMatch == Domain.TagMatchAny {
+			conds = append(conds, bson.M{"tags": bson.M{"$in": f.Tags}})
+		} else {
+			conds = append(conds, bson.M{"tags": bson.M{"$all": f.Tags}})
+		}
+	}
+	if f.Assignee != "" {
+		conds = append(conds, bson.M{"assignees": f.Assignee})
+	}
+	if f.Status != "" {
+		conds = append(conds, bson.M{"status": f.Status})
+	}
+	if f.Priority != "" {
+		conds = append(conds, bson.M{"priority": f.Priority})
+	}
+	if f.DueAfter != nil {
+		conds = append(conds, bson.M{"due_date": bson.M{"$gte": *f.DueAfter}})
+	}
+	if f.DueBefore != nil {
+		conds = append(conds, bson.M{"due_date": bson.M{"$lte": *f.DueBefore}})
+	}
+	for field, bound := range map[string]*time.Time{
+		"created_at": f.CreatedAfter,
+		"updated_at": f.UpdatedAfter,
+	} {
+		if bound != nil {
+			conds = append(conds, bson.M{field: bson.M{"$gte": *bound}})
+		}
+	}
+	for field, bound := range map[string]*time.Time{
+		"created_at": f.CreatedBefore,
+		"updated_at": f.UpdatedBefore,
+	} {
+		if bound != nil {
+			conds = append(conds, bson.M{field: bson.M{"$lte": *bound}})
+		}
+	}
+	if f.CreatedBy != "" {
+		conds = append(conds, bson.M{"created_by": f.CreatedBy})
+	}
+	if f.UpdatedBy != "" {
+		conds = append(conds, bson.M{"updated_by": f.UpdatedBy})
+	}
+	if f.Overdue {
+		conds = append(conds,
+			bson.M{"due_date": bson.M{"$lt": time.Now()}},
+			bson.M{"status": bson.M{"$ne": Domain.StatusDone}},
+		)
+	}
+	if f.TitleContains != "" {
+		conds = append(conds, bson.M{"title": primitive.Regex{Pattern: regexp.QuoteMeta(f.TitleContains), Options: "i"}})
+	}
+	return bson.M{"$and": conds}
+}
+
+// taskSortKeys returns the keyset ordering for a TaskFilter.SortBy value.
+func taskSortKeys(sortBy string, desc bool) ([]sortKey, error) {
+	switch sortBy {
+	case "", Domain.SortByCreatedAt:
+		return []sortKey{
+			{field: "created_at", desc: desc, value: func(t Domain.Task) interface{} { return t.CreatedAt }},
+			idKey(desc),
+		}, nil
+	case Domain.SortByUpdatedAt:
+		return []sortKey{
+			{field: "updated_at", desc: desc, value: func(t Domain.Task) interface{} { return t.UpdatedAt }},
+			idKey(desc),
+		}, nil
+	case Domain.SortByDueDate:
+		return []sortKey{dueDateKey(desc), idKey(desc)}, nil
+	case Domain.SortByPriority:
+		// the direction only applies to the priority; within a priority the
+		// soonest due tasks come first
+		return []sortKey{
+			{field: "priority_rank", desc: desc, value: func(t Domain.Task) interface{} {
+				if t.PriorityRank == 0 {
+					return nil
+				}
+				return t.PriorityRank
+			}},
+			dueDateKey(false),
+			idKey(false),
+		}, nil
+	case Domain.SortByDeletedAt:
+		return []sortKey{
+			{field: "deleted_at", desc: desc, value: func(t Domain.Task) interface{} {
+				if t.DeletedAt == nil {
+					return nil
+				}
+				return *t.DeletedAt
+			}},
+			idKey(desc),
+		}, nil
+	}
+	return nil, Domain.ErrInvalidQuery
+}
+
+func dueDateKey(desc bool) sortKey {
+	return sortKey{field: "due_date", desc: desc, value: func(t Domain.Task) interface{} {
+		if t.DueDate == nil {
+			return nil
+		}
+		return *t.DueDate
+	}}
+}
+
+func (r *mongoTaskRepository) FindAll(ctx context.Context, f Domain.TaskFilter) (Domain.TaskPage, error) {
 	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
-	cur, err := r.coll.Find(ctx, bson.D{})
+
+	var position pageCursor
+	if f.Cursor != "" {
+		var err error
+		if position, err = decodeCursor(f.Cursor); err != nil {
+			return Domain.TaskPage{}, Domain.ErrInvalidCursor
+		}
+	}
+	sortBy, desc := f.SortBy, f.SortDesc
+	// the default order used to be oldest first; cursors issued under it
+	// carry no sort name and keep paging in that order
+	if sortBy == "" && (f.Cursor == "" || position.Sort != "") {
+		sortBy, desc = Domain.SortByPriority, true
+	}
+	keys, err := taskSortKeys(sortBy, desc)
 	if err != nil {
-		return nil, err
+		return Domain.TaskPage{}, err
+	}
+	sortName := sortBy
+	if desc {
+		sortName = "-" + sortName
+	}
+	query, err := scoped(ctx, buildTaskFilter(f))
+	if err != nil {
+		return Domain.TaskPage{}, err
+	}
+	if f.Cursor != "" {
+		if position.Sort != sortName {
+			return Domain.TaskPage{}, Domain.ErrInvalidCursor
+		}
+		after, err := keysetAfter(keys, position)
+		if err != nil {
+			return Domain.TaskPage{}, err
+		}
+		query = bson.M{"$and": bson.A{query, after}}
+	}
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = Domain.DefaultPageLimit
+	}
+	if limit > Domain.MaxPageLimit {
+		limit = Domain.MaxPageLimit
+	}
+	// fetch one extra document to learn whether another page exists
+	opts := options.Find().SetSort(sortDoc(keys)).SetLimit(int64(limit + 1))
+	cur, err := r.coll.Find(ctx, query, opts)
+	if err != nil {
+		return Domain.TaskPage{}, err
 	}
 	defer cur.Close(ctx)
 
-	var tasks []Domain.Task
+	tasks := []Domain.Task{}
 	for cur.Next(ctx) {
 		var t Domain.Task
 		if err := cur.Decode(&t); err != nil {
-			return nil, err
+			return Domain.TaskPage{}, err
 		}
 		tasks = append(tasks, t)
 	}
-	return tasks, cur.Err()
+	if err := cur.Err(); err != nil {
+		return Domain.TaskPage{}, err
+	}
+
+	page := Domain.TaskPage{Tasks: tasks}
+	if len(tasks) > limit {
+		page.Tasks = tasks[:limit]
+		page.HasMore = true
+		page.NextCursor, err = cursorFor(sortName, keys, page.Tasks[limit-1])
+		if err != nil {
+			return Domain.TaskPage{}, err
+		}
+	}
+	return page, nil
 }
 
 func (r *mongoTaskRepository) FindByID(ctx context.Context, hexID string) (Domain.Task, error) {
@@ -57,8 +286,12 @@ func (r *mongoTaskRepository) FindByID(ctx context.Context, hexID string) (Domai
 	if err != nil {
 		return Domain.Task{}, errors.New("invalid id")
 	}
+	filter, err := scoped(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return Domain.Task{}, err
+	}
 	var t Domain.Task
-	if err := r.coll.FindOne(ctx, bson.M{"_id": oid}).Decode(&t); err != nil {
+	if err := r.coll.FindOne(ctx, filter).Decode(&t); err != nil {
 		if err == mongo.ErrNoDocuments {
 			return Domain.Task{}, nil
 		}
@@ -67,11 +300,115 @@ func (r *mongoTaskRepository) FindByID(ctx context.Context, hexID string) (Domai
 	return t, nil
 }
 
+// FindByIDs returns the tasks with the given ids, including trashed ones.
+// Ids that do not exist are skipped.
+func (r *mongoTaskRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]Domain.Task, error) {
+	if len(ids) == 0 {
+		return []Domain.Task{}, nil
+	}
+	return r.findMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+// FindBlockedBy returns the live tasks that list any of blockerIDs as a blocker.
+func (r *mongoTaskRepository) FindBlockedBy(ctx context.Context, blockerIDs []primitive.ObjectID) ([]Domain.Task, error) {
+	if len(blockerIDs) == 0 {
+		return []Domain.Task{}, nil
+	}
+	return r.findMany(ctx, bson.M{"blocked_by": bson.M{"$in": blockerIDs}, "deleted_at": nil})
+}
+
+// FindSubtasks returns the ids of the direct subtasks of parentIDs, including
+// trashed ones. Only _id and parent_id are filled in.
+func (r *mongoTaskRepository) FindSubtasks(ctx context.Context, parentIDs []primitive.ObjectID) ([]Domain.Task, error) {
+	if len(parentIDs) == 0 {
+		return []Domain.Task{}, nil
+	}
+	return r.findMany(ctx, bson.M{"parent_id": bson.M{"$in": parentIDs}},
+		options.Find().SetProjection(bson.M{"_id": 1, "parent_id": 1}))
+}
+
+func (r *mongoTaskRepository) findMany(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]Domain.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	filter, err := scoped(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := r.coll.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	tasks := []Domain.Task{}
+	if err := cur.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// versionFilter matches the task by id and, when ifVersion is set, by version.
+// Tasks stored before versioning have no version field and count as version 0.
+func versionFilter(oid primitive.ObjectID, ifVersion *int64) bson.M {
+	filter := bson.M{"_id": oid}
+	if ifVersion != nil {
+		if *ifVersion == 0 {
+			filter["version"] = bson.M{"$in": bson.A{0, nil}}
+		} else {
+			filter["version"] = *ifVersion
+		}
+	}
+	return filter
+}
+
+// changesFilter matches the task changes apply to, honouring their
+// IfVersion and IfLive conditions.
+func changesFilter(oid primitive.ObjectID, changes Domain.TaskChanges) bson.M {
+	filter := versionFilter(oid, changes.IfVersion)
+	if changes.IfLive {
+		filter["deleted_at"] = nil
+	}
+	return filter
+}
+
+// missReason explains why a conditional write matched nothing: the task is
+// either gone (nil error) or at another version (ErrVersionMismatch).
+func (r *mongoTaskRepository) missReason(ctx context.Context, oid primitive.ObjectID, changes Domain.TaskChanges) error {
+	if changes.IfVersion == nil {
+		return nil
+	}
+	live := changes
+	live.IfVersion = nil
+	filter, err := scoped(ctx, changesFilter(oid, live))
+	if err != nil {
+		return err
+	}
+	n, err := r.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return Domain.ErrVersionMismatch
+	}
+	return nil
+}
+
 func (r *mongoTaskRepository) Create(ctx context.Context, t Domain.Task) (Domain.Task, error) {
 	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
+	tenant, err := tenantFor(ctx, t.TenantID)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	t.TenantID = tenant
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	t.Version = 1
+	t.CreatedAt = now
+	t.UpdatedAt = now
+	t.UpdatedBy = t.CreatedBy
 	res, err := r.coll.InsertOne(ctx, t)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) && t.Recurrence != nil {
+			return Domain.Task{}, Domain.ErrOccurrenceExists
+		}
 		return Domain.Task{}, err
 	}
 	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
@@ -80,50 +417,203 @@ func (r *mongoTaskRepository) Create(ctx context.Context, t Domain.Task) (Domain
 	return t, nil
 }
 
-func (r *mongoTaskRepository) Update(ctx context.Context, hexID string, updated Domain.Task) (Domain.Task, error) {
+func (r *mongoTaskRepository) Update(ctx context.Context, hexID string, changes Domain.TaskChanges) (Domain.Task, error) {
 	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 	oid, err := primitive.ObjectIDFromHex(hexID)
 	if err != nil {
 		return Domain.Task{}, errors.New("invalid id")
 	}
-	updateDoc := bson.M{}
-	if updated.Title != "" {
-		updateDoc["title"] = updated.Title
+	if len(changes.Set) == 0 && len(changes.Unset) == 0 && len(changes.AddToSet) == 0 && len(changes.Pull) == 0 {
+		return Domain.Task{}, Domain.ErrNoFieldsToUpdate
+	}
+	set := bson.M{"updated_at": time.Now().UTC().Truncate(time.Millisecond)}
+	for field, value := range changes.Set {
+		set[field] = value
 	}
-	if updated.Description != "" {
-		updateDoc["description"] = updated.Description
+	if changes.UpdatedBy != "" {
+		set["updated_by"] = changes.UpdatedBy
 	}
-	if updated.DueDate != "" {
-		updateDoc["due_date"] = updated.DueDate
+	updateDoc := bson.M{"$set": set}
+	if len(changes.Unset) > 0 {
+		unset := bson.M{}
+		for _, field := range changes.Unset {
+			unset[field] = ""
+		}
+		updateDoc["$unset"] = unset
 	}
-	if updated.Status != "" {
-		updateDoc["status"] = updated.Status
+	if len(changes.AddToSet) > 0 {
+		updateDoc["$addToSet"] = bson.M(changes.AddToSet)
 	}
-	if len(updateDoc) == 0 {
-		return Domain.Task{}, errors.New("no fields to update")
+	if len(changes.Pull) > 0 {
+		updateDoc["$pull"] = bson.M(changes.Pull)
+	}
+	return r.findAndUpdate(ctx, oid, changes, updateDoc)
+}
+
+// findAndUpdate applies update to the task, bumping its version, and returns
+// the updated document. An empty task means it does not exist.
+func (r *mongoTaskRepository) findAndUpdate(ctx context.Context, oid primitive.ObjectID, changes Domain.TaskChanges, update bson.M) (Domain.Task, error) {
+	filter, err := scoped(ctx, changesFilter(oid, changes))
+	if err != nil {
+		return Domain.Task{}, err
 	}
+	update["$inc"] = bson.M{"version": 1}
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 	var result Domain.Task
-	if err := r.coll.FindOneAndUpdate(ctx, bson.M{"_id": oid}, bson.M{"$set": updateDoc}, opts).Decode(&result); err != nil {
+	if err := r.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&result); err != nil {
 		if err == mongo.ErrNoDocuments {
-			return Domain.Task{}, nil
+			return Domain.Task{}, r.missReason(ctx, oid, changes)
 		}
 		return Domain.Task{}, err
 	}
 	return result, nil
 }
 
-func (r *mongoTaskRepository) Delete(ctx context.Context, hexID string) (bool, error) {
+// Purge permanently removes tasks that were moved to the trash before
+// deletedBefore and returns them. A task restored or deleted again while the
+// purge runs is left alone.
+func (r *mongoTaskRepository) Purge(ctx context.Context, deletedBefore time.Time) ([]Domain.Task, error) {
+	filter, err := scoped(ctx, bson.M{"deleted_at": bson.M{"$lt": deletedBefore}})
+	if err != nil {
+		return nil, err
+	}
+	purged := []Domain.Task{}
+	for {
+		batch, n, err := r.purgeBatch(ctx, filter)
+		purged = append(purged, batch...)
+		if err != nil || n < purgeBatchSize {
+			return purged, err
+		}
+	}
+}
+
+// purgeBatch removes up to purgeBatchSize tasks matching filter. It returns
+// the removed tasks and how many candidates it found, so Purge knows when the
+// trash is drained.
+func (r *mongoTaskRepository) purgeBatch(ctx context.Context, filter bson.M) ([]Domain.Task, int, error) {
 	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
-	oid, err := primitive.ObjectIDFromHex(hexID)
+	opts := options.Find().SetSort(bson.D{{Key: "deleted_at", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(purgeBatchSize)
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	var candidates []Domain.Task
+	if err := cur.All(ctx, &candidates); err != nil {
+		return nil, 0, err
+	}
+
+	purged := []Domain.Task{}
+	for _, t := range candidates {
+		res, err := r.coll.DeleteOne(ctx, bson.M{"_id": t.ID, "deleted_at": *t.DeletedAt})
+		if err != nil {
+			return purged, len(candidates), err
+		}
+		if res.DeletedCount > 0 {
+			purged = append(purged, t)
+		}
+	}
+	return purged, len(candidates), nil
+}
+
+// CountSubtasks counts the live children of each parent and how many of them are done.
+func (r *mongoTaskRepository) CountSubtasks(ctx context.Context, parentIDs []primitive.ObjectID) (map[primitive.ObjectID]Domain.SubtaskCounts, error) {
+	counts := map[primitive.ObjectID]Domain.SubtaskCounts{}
+	if len(parentIDs) == 0 {
+		return counts, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	match, err := scoped(ctx, bson.M{"parent_id": bson.M{"$in": parentIDs}, "deleted_at": nil})
+	if err != nil {
+		return nil, err
+	}
+	cur, err := r.coll.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$parent_id",
+			"total": bson.M{"$sum": 1},
+			"done":  bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", Domain.StatusDone}}, 1, 0}}},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	for cur.Next(ctx) {
+		var row struct {
+			ID    primitive.ObjectID `bson:"_id"`
+			Total int                `bson:"total"`
+			Done  int                `bson:"done"`
+		}
+		if err := cur.Decode(&row); err != nil {
+			return nil, err
+		}
+		counts[row.ID] = Domain.SubtaskCounts{Total: row.Total, Done: row.Done}
+	}
+	return counts, cur.Err()
+}
+
+// FindRecurring returns live occurrences of recurring series, due before
+// dueBefore, whose following occurrence has not been created yet. They are
+// paged by id: after is the last id of the previous page, or the zero id.
+func (r *mongoTaskRepository) FindRecurring(ctx context.Context, dueBefore time.Time, after primitive.ObjectID, limit int) ([]Domain.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	filter := bson.M{
+		"recurrence.series_id":    bson.M{"$exists": true},
+		"recurrence.next_created": bson.M{"$ne": true},
+		"due_date":                bson.M{"$lt": dueBefore},
+		"deleted_at":              nil,
+	}
+	if !after.IsZero() {
+		filter["_id"] = bson.M{"$gt": after}
+	}
+	filter, err := scoped(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	cur, err := r.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	tasks := []Domain.Task{}
+	if err := cur.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// MarkNextCreated flags an occurrence as followed by the next one (or as the
+// end of its series). It is bookkeeping for the materialiser, so the task's
+// version and timestamps are left alone.
+func (r *mongoTaskRepository) MarkNextCreated(ctx context.Context, id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	filter, err := scoped(ctx, bson.M{"_id": id})
 	if err != nil {
-		return false, errors.New("invalid id")
+		return err
 	}
-	res, err := r.coll.DeleteOne(ctx, bson.M{"_id": oid})
+	_, err = r.coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"recurrence.next_created": true}})
+	return err
+}
+
+// FindByTag returns up to limit tasks of the organisation carrying the named
+// tag, including trashed ones.
+func (r *mongoTaskRepository) FindByTag(ctx context.Context, name string, limit int) ([]Domain.Task, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	return r.findMany(ctx, bson.M{"tags": name}, opts)
+}
+
+// CountByProject counts the tasks of a project, including those in the trash.
+func (r *mongoTaskRepository) CountByProject(ctx context.Context, projectID primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	filter, err := scoped(ctx, bson.M{"project_id": projectID})
 	if err != nil {
-		return false, err
+		return 0, err
 	}
-	return res.DeletedCount > 0, nil
+	return r.coll.CountDocuments(ctx, filter)
 }
@@ -0,0 +1,44 @@
+package Repositories
+
+import (
+	"context"
+	"errors"
+
+	"task_manager1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var errTenantMismatch = errors.New("document belongs to another organization")
+
+// scoped restricts filter to the organisation ctx is scoped to. Contexts made
+// by Domain.AllTenants are left unrestricted; any other context fails with
+// Domain.ErrNoTenant so a missing scope can never read across organisations.
+func scoped(ctx context.Context, filter bson.M) (bson.M, error) {
+	id, all, ok := Domain.TenantFromContext(ctx)
+	if !ok || (!all && id.IsZero()) {
+		return nil, Domain.ErrNoTenant
+	}
+	if !all {
+		filter["tenant_id"] = id
+	}
+	return filter, nil
+}
+
+// tenantFor returns the organisation a new document is stored under: id when
+// set, otherwise the organisation of ctx. A scoped context cannot create
+// documents in another organisation.
+func tenantFor(ctx context.Context, id primitive.ObjectID) (primitive.ObjectID, error) {
+	scope, all, ok := Domain.TenantFromContext(ctx)
+	switch {
+	case !ok || (id.IsZero() && scope.IsZero()):
+		return primitive.NilObjectID, Domain.ErrNoTenant
+	case all || id == scope:
+		return id, nil
+	case id.IsZero():
+		return scope, nil
+	default:
+		return primitive.NilObjectID, errTenantMismatch
+	}
+}
@@ -13,12 +13,16 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// UserRepository defines user data methods
+// UserRepository defines user data methods. Usernames are unique across
+// organisations so a login identifies its organisation.
 type UserRepository interface {
 	Create(ctx context.Context, u Domain.User) (Domain.User, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (Domain.User, error)
 	FindByUsername(ctx context.Context, username string) (Domain.User, error)
 	PromoteToAdmin(ctx context.Context, username string) (Domain.User, error)
-	Count(ctx context.Context) (int64, error)
+	DemoteToUser(ctx context.Context, username string) (Domain.User, error)
+	SetDisabled(ctx context.Context, username string, disabled bool) (Domain.User, error)
+	SetTimezone(ctx context.Context, username, timezone string) (Domain.User, error)
 }
 
 type mongoUserRepository struct {
@@ -30,9 +34,9 @@ func NewMongoUserRepository(coll *mongo.Collection) UserRepository {
 	// ensure unique username index
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	_, _ = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys:    bson.D{{Key: "username", Value: 1}},
-		Options: options.Index().SetUnique(true),
+	_, _ = coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "username", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "tenant_id", Value: 1}}},
 	})
 	return &mongoUserRepository{coll: coll, timeout: 5 * time.Second}
 }
@@ -41,6 +45,11 @@ func (r *mongoUserRepository) Create(ctx context.Context, u Domain.User) (Domain
 	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
+	tenant, err := tenantFor(ctx, u.TenantID)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	u.TenantID = tenant
 	res, err := r.coll.InsertOne(ctx, u)
 	if err != nil {
 		if mongo.IsDuplicateKeyError(err) {
@@ -55,11 +64,26 @@ func (r *mongoUserRepository) Create(ctx context.Context, u Domain.User) (Domain
 	return u, nil
 }
 
+// FindByID returns a user without its hash, or an empty user.
+func (r *mongoUserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (Domain.User, error) {
+	u, err := r.findOne(ctx, bson.M{"_id": id})
+	u.PasswordHash = ""
+	return u, err
+}
+
 func (r *mongoUserRepository) FindByUsername(ctx context.Context, username string) (Domain.User, error) {
+	return r.findOne(ctx, bson.M{"username": username})
+}
+
+func (r *mongoUserRepository) findOne(ctx context.Context, filter bson.M) (Domain.User, error) {
 	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
+	filter, err := scoped(ctx, filter)
+	if err != nil {
+		return Domain.User{}, err
+	}
 	var u Domain.User
-	err := r.coll.FindOne(ctx, bson.M{"username": username}).Decode(&u)
+	err = r.coll.FindOne(ctx, filter).Decode(&u)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return Domain.User{}, nil
@@ -69,13 +93,36 @@ func (r *mongoUserRepository) FindByUsername(ctx context.Context, username strin
 	return u, nil
 }
 
+// PromoteToAdmin, DemoteToUser and SetDisabled bump token_version so access
+// tokens issued before the change stop working.
 func (r *mongoUserRepository) PromoteToAdmin(ctx context.Context, username string) (Domain.User, error) {
+	return r.update(ctx, username, bson.M{"$set": bson.M{"role": "admin"}, "$inc": bson.M{"token_version": 1}})
+}
+
+func (r *mongoUserRepository) DemoteToUser(ctx context.Context, username string) (Domain.User, error) {
+	return r.update(ctx, username, bson.M{"$set": bson.M{"role": "user"}, "$inc": bson.M{"token_version": 1}})
+}
+
+func (r *mongoUserRepository) SetDisabled(ctx context.Context, username string, disabled bool) (Domain.User, error) {
+	return r.update(ctx, username, bson.M{"$set": bson.M{"disabled": disabled}, "$inc": bson.M{"token_version": 1}})
+}
+
+func (r *mongoUserRepository) SetTimezone(ctx context.Context, username, timezone string) (Domain.User, error) {
+	return r.update(ctx, username, bson.M{"$set": bson.M{"timezone": timezone}})
+}
+
+// update applies update to the user of the organisation and returns the
+// result without its hash, or an empty user when there is no such user.
+func (r *mongoUserRepository) update(ctx context.Context, username string, update bson.M) (Domain.User, error) {
 	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
+	filter, err := scoped(ctx, bson.M{"username": username})
+	if err != nil {
+		return Domain.User{}, err
+	}
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
-	update := bson.M{"$set": bson.M{"role": "admin"}}
 	var updated Domain.User
-	if err := r.coll.FindOneAndUpdate(ctx, bson.M{"username": username}, update, opts).Decode(&updated); err != nil {
+	if err := r.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated); err != nil {
 		if err == mongo.ErrNoDocuments {
 			return Domain.User{}, nil
 		}
@@ -84,9 +131,3 @@ func (r *mongoUserRepository) PromoteToAdmin(ctx context.Context, username strin
 	updated.PasswordHash = ""
 	return updated, nil
 }
-
-func (r *mongoUserRepository) Count(ctx context.Context) (int64, error) {
-	ctx, cancel := context.WithTimeout(ctx, r.timeout)
-	defer cancel()
-	return r.coll.CountDocuments(ctx, bson.M{})
-}
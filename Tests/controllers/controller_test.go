@@ -1,11 +1,161 @@
 package controllers_test
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"task_manager1/Delivery/controllers"
+	"task_manager1/Domain"
+	"task_manager1/Infrastructure/auth"
+	"task_manager1/Tests/mocks"
+	"task_manager1/Usecases"
 )
 
 func TestPlaceholder(t *testing.T) {
 	assert.True(t, true)
 }
+
+// newTaskRepo returns a task repository mock whose tasks have no subtasks.
+func newTaskRepo() *mocks.MockTaskRepository {
+	repo := new(mocks.MockTaskRepository)
+	repo.On("CountSubtasks", mock.Anything, mock.Anything).Return(map[primitive.ObjectID]Domain.SubtaskCounts{}, nil).Maybe()
+	return repo
+}
+
+// anyProjects is a project repository mock in which the actor belongs to no project.
+func anyProjects() *mocks.MockProjectRepository {
+	p := new(mocks.MockProjectRepository)
+	p.On("ListByMember", mock.Anything, mock.Anything).Return([]Domain.Project{}, nil).Maybe()
+	return p
+}
+
+// newTaskRouter wires the task handlers behind a fake auth step that acts as owner.
+func newTaskRouter(repo *mocks.MockTaskRepository, history *mocks.MockHistoryRepository, owner primitive.ObjectID) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	taskUC := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), history, new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+	ctl := controllers.NewController(nil, taskUC, nil, nil, nil)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		auth.SetPrincipal(c, auth.Principal{UserID: owner, Username: "kidus", Role: "user"})
+	})
+	r.GET("/tasks", ctl.GetTasks)
+	r.GET("/tasks/:id", ctl.GetTaskByID)
+	r.PUT("/tasks/:id", ctl.UpdateTask)
+	r.GET("/tasks/:id/history", ctl.GetTaskHistory)
+	return r
+}
+
+func TestGetTaskByIDHonorsIfNoneMatch(t *testing.T) {
+	repo := newTaskRepo()
+	owner := primitive.NewObjectID()
+	id := primitive.NewObjectID()
+	repo.On("FindByID", mock.Anything, id.Hex()).Return(Domain.Task{ID: id, OwnerID: owner, Title: "t", Version: 7}, nil)
+	r := newTaskRouter(repo, new(mocks.MockHistoryRepository), owner)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks/"+id.Hex(), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"7"`, w.Header().Get("ETag"))
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks/"+id.Hex(), nil)
+	req.Header.Set("If-None-Match", `"7"`)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+}
+
+func TestUpdateTaskReturnsPreconditionFailedOnStaleIfMatch(t *testing.T) {
+	repo := newTaskRepo()
+	owner := primitive.NewObjectID()
+	id := primitive.NewObjectID()
+	repo.On("FindByID", mock.Anything, id.Hex()).Return(Domain.Task{ID: id, OwnerID: owner, Title: "t", Version: 7}, nil)
+	r := newTaskRouter(repo, new(mocks.MockHistoryRepository), owner)
+
+	req := httptest.NewRequest(http.MethodPut, "/tasks/"+id.Hex(), strings.NewReader(`{"title":"new"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"6"`)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	// weak tags never match under If-Match's strong comparison
+	req = httptest.NewRequest(http.MethodPut, "/tasks/"+id.Hex(), strings.NewReader(`{"title":"new"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `W/"7"`)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+}
+
+func TestUpdateTaskRaceIsConflictWithoutIfMatch(t *testing.T) {
+	repo := newTaskRepo()
+	owner := primitive.NewObjectID()
+	id := primitive.NewObjectID()
+	repo.On("FindByID", mock.Anything, id.Hex()).Return(Domain.Task{ID: id, OwnerID: owner, Title: "t", Version: 7}, nil)
+	// another request wins between the read and the write
+	repo.On("Update", mock.Anything, id.Hex(), mock.Anything).Return(Domain.Task{}, Domain.ErrVersionMismatch)
+	r := newTaskRouter(repo, new(mocks.MockHistoryRepository), owner)
+
+	req := httptest.NewRequest(http.MethodPut, "/tasks/"+id.Hex(), strings.NewReader(`{"title":"new"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// with If-Match the client's precondition is what failed
+	req = httptest.NewRequest(http.MethodPut, "/tasks/"+id.Hex(), strings.NewReader(`{"title":"new"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"7"`)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+}
+
+func TestGetTaskHistoryAtReturnsPastState(t *testing.T) {
+	repo := newTaskRepo()
+	history := new(mocks.MockHistoryRepository)
+	owner := primitive.NewObjectID()
+	id := primitive.NewObjectID()
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo.On("FindByID", mock.Anything, id.Hex()).Return(Domain.Task{ID: id, OwnerID: owner, Title: "now", Version: 3}, nil)
+	history.On("AsOf", mock.Anything, id.Hex(), at).Return(Domain.TaskHistoryEntry{
+		TaskID: id, Action: Domain.HistoryCreated, At: at.Add(-time.Hour), Version: 1,
+		Snapshot: Domain.Task{ID: id, OwnerID: owner, Title: "then", Version: 1},
+	}, nil)
+	r := newTaskRouter(repo, history, owner)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks/"+id.Hex()+"/history?at=2024-03-01T12:00:00Z", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"title":"then"`)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks/"+id.Hex()+"/history?at=yesterday", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetTasksPassesTagFilter(t *testing.T) {
+	repo := newTaskRepo()
+	owner := primitive.NewObjectID()
+	repo.On("FindAll", mock.Anything, mock.MatchedBy(func(f Domain.TaskFilter) bool {
+		return assert.ObjectsAreEqual([]string{"urgent", "backend"}, f.Tags) && f.TagMatch == Domain.TagMatchAny
+	})).Return(Domain.TaskPage{Tasks: []Domain.Task{{ID: primitive.NewObjectID(), OwnerID: owner, Title: "t", Tags: []string{"backend"}}}}, nil)
+	repo.On("FindBlockedBy", mock.Anything, mock.Anything).Return([]Domain.Task{}, nil).Maybe()
+	r := newTaskRouter(repo, new(mocks.MockHistoryRepository), owner)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks?tag=Urgent&tag=backend&tag_match=any", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"tags":["backend"]`)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks?tag=x&tag_match=some", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,70 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"task_manager1/Domain"
+)
+
+func TestParseRRuleNormalisesAndRejectsUnsupportedParts(t *testing.T) {
+	r, err := Domain.ParseRRule("RRULE:freq=weekly;byday=MO,WE")
+	assert.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,WE", r.String())
+
+	for _, spec := range []string{"", "BYDAY=MO", "FREQ=HOURLY", "FREQ=DAILY;BYSETPOS=1", "FREQ=WEEKLY;BYDAY=-1FR", "FREQ=DAILY;COUNT=2;UNTIL=20250101"} {
+		_, err := Domain.ParseRRule(spec)
+		assert.ErrorIs(t, err, Domain.ErrInvalidRecurrence, spec)
+	}
+}
+
+func TestRRuleNextOccurrence(t *testing.T) {
+	nairobi, _ := time.LoadLocation("Africa/Nairobi")
+	// Monday 6 Jan 2025, 09:00 in Nairobi
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, nairobi)
+
+	cases := []struct {
+		rule  string
+		after time.Time
+		want  time.Time
+	}{
+		{"FREQ=DAILY", start, time.Date(2025, 1, 7, 9, 0, 0, 0, nairobi)},
+		{"FREQ=WEEKLY;BYDAY=MO,WE", start, time.Date(2025, 1, 8, 9, 0, 0, 0, nairobi)},
+		{"FREQ=WEEKLY;BYDAY=MO,WE", time.Date(2025, 1, 8, 9, 0, 0, 0, nairobi), time.Date(2025, 1, 13, 9, 0, 0, 0, nairobi)},
+		{"FREQ=WEEKLY;INTERVAL=2", start, time.Date(2025, 1, 20, 9, 0, 0, 0, nairobi)},
+		{"FREQ=MONTHLY;BYDAY=-1FR", start, time.Date(2025, 1, 31, 9, 0, 0, 0, nairobi)},
+		{"FREQ=MONTHLY;BYDAY=-1FR", time.Date(2025, 1, 31, 9, 0, 0, 0, nairobi), time.Date(2025, 2, 28, 9, 0, 0, 0, nairobi)},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", start, time.Date(2025, 1, 31, 9, 0, 0, 0, nairobi)},
+		{"FREQ=YEARLY;BYMONTH=3;BYDAY=2TU", start, time.Date(2025, 3, 11, 9, 0, 0, 0, nairobi)},
+		// far from the start the search jumps straight to the right period
+		{"FREQ=DAILY", time.Date(2045, 5, 1, 12, 0, 0, 0, nairobi), time.Date(2045, 5, 2, 9, 0, 0, 0, nairobi)},
+	}
+	for _, c := range cases {
+		r, err := Domain.ParseRRule(c.rule)
+		assert.NoError(t, err, c.rule)
+		next, ok := r.Next(start, c.after, 1, nairobi)
+		assert.True(t, ok, c.rule)
+		assert.True(t, c.want.Equal(next), "%s: got %s want %s", c.rule, next.In(nairobi), c.want)
+	}
+}
+
+func TestRRuleSeriesEnds(t *testing.T) {
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+
+	r, _ := Domain.ParseRRule("FREQ=DAILY;COUNT=3")
+	_, ok := r.Next(start, start.AddDate(0, 0, 1), 2, time.UTC)
+	assert.True(t, ok)
+	_, ok = r.Next(start, start.AddDate(0, 0, 2), 3, time.UTC)
+	assert.False(t, ok)
+
+	r, _ = Domain.ParseRRule("FREQ=DAILY;UNTIL=20250107")
+	_, ok = r.Next(start, start, 1, time.UTC)
+	assert.True(t, ok)
+	_, ok = r.Next(start, start.AddDate(0, 0, 1), 2, time.UTC)
+	assert.False(t, ok)
+
+	r, _ = Domain.ParseRRule("FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30")
+	_, ok = r.Next(start, start, 1, time.UTC)
+	assert.False(t, ok)
+}
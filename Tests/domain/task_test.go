@@ -0,0 +1,50 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"task_manager1/Domain"
+)
+
+func TestTaskProgressCombinesSubtasksAndChecklist(t *testing.T) {
+	assert.Nil(t, Domain.Task{}.Progress())
+
+	task := Domain.Task{
+		Checklist: []Domain.ChecklistItem{{Text: "a", Done: true}, {Text: "b"}},
+		Subtasks:  Domain.SubtaskCounts{Total: 2, Done: 1},
+	}
+	assert.Equal(t, 50, *task.Progress())
+
+	task.Subtasks.Done = 2
+	assert.Equal(t, 75, *task.Progress())
+}
+
+func TestParseMentionsSkipsEmailAddresses(t *testing.T) {
+	assert.Equal(t, []string{"abebe", "sara.k"}, Domain.ParseMentions("@abebe, see kidus@example.com and ask @sara.k. Thanks @abebe!"))
+	assert.Empty(t, Domain.ParseMentions("no mentions @ all"))
+}
+
+func TestProjectRolesGateTaskAccess(t *testing.T) {
+	owner, editor, viewer, outsider := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	p := Domain.Project{ID: primitive.NewObjectID(), Members: []Domain.ProjectMember{
+		{UserID: owner, Role: Domain.ProjectOwner},
+		{UserID: editor, Role: Domain.ProjectEditor},
+		{UserID: viewer, Role: Domain.ProjectViewer},
+	}}
+	task := Domain.Task{ProjectID: p.ID, OwnerID: outsider, SharedWith: []primitive.ObjectID{outsider}}
+
+	assert.True(t, task.CanView(Domain.Actor{UserID: viewer}, p))
+	assert.False(t, task.CanModify(Domain.Actor{UserID: viewer}, p))
+	assert.True(t, task.CanModify(Domain.Actor{UserID: editor}, p))
+	assert.True(t, p.CanManage(Domain.Actor{UserID: owner}))
+	assert.False(t, p.CanManage(Domain.Actor{UserID: editor}))
+
+	// sharing grants read access only; owning a task no longer grants anything
+	assert.True(t, task.CanView(Domain.Actor{UserID: outsider}, p))
+	assert.False(t, task.CanModify(Domain.Actor{UserID: outsider}, p))
+	task.SharedWith = nil
+	assert.False(t, task.CanView(Domain.Actor{UserID: outsider}, p))
+	assert.True(t, task.CanModify(Domain.Actor{Role: "admin"}, p))
+}
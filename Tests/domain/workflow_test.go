@@ -0,0 +1,32 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"task_manager1/Domain"
+)
+
+func TestDefaultWorkflowTransitions(t *testing.T) {
+	wf := Domain.DefaultWorkflow(false)
+
+	assert.True(t, wf.CanTransition(Domain.StatusTodo, Domain.StatusInProgress))
+	assert.True(t, wf.CanTransition(Domain.StatusReview, Domain.StatusDone))
+	assert.False(t, wf.CanTransition(Domain.StatusTodo, Domain.StatusDone))
+	assert.False(t, wf.CanTransition(Domain.StatusDone, Domain.StatusTodo))
+
+	reopen := Domain.DefaultWorkflow(true)
+	assert.True(t, reopen.CanTransition(Domain.StatusDone, Domain.StatusTodo))
+}
+
+func TestParseWorkflow(t *testing.T) {
+	wf, err := Domain.ParseWorkflow("open>doing; doing>done,open; done")
+	assert.NoError(t, err)
+	assert.Equal(t, "open", wf.Initial)
+	assert.Equal(t, []string{"open", "doing", "done"}, wf.States)
+	assert.Equal(t, []string{"done", "open"}, wf.Next("doing"))
+	assert.Empty(t, wf.Next("done"))
+
+	_, err = Domain.ParseWorkflow("open>closed")
+	assert.Error(t, err)
+}
@@ -0,0 +1,35 @@
+package infrastructure_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"task_manager1/Infrastructure/storage"
+)
+
+func TestLocalBlobStoreRoundTrip(t *testing.T) {
+	store, err := storage.NewLocalBlobStore(t.TempDir())
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	n, err := store.Put(ctx, "abc123", strings.NewReader("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+
+	r, err := store.Open(ctx, "abc123")
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "hello", string(body))
+
+	assert.NoError(t, store.Delete(ctx, "abc123"))
+	assert.NoError(t, store.Delete(ctx, "abc123"))
+	_, err = store.Open(ctx, "abc123")
+	assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+
+	_, err = store.Put(ctx, "../escape", strings.NewReader("x"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,75 @@
+package infrastructure_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"task_manager1/Infrastructure/auth"
+)
+
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaDER, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	require.NoError(t, err)
+	rsaPublic, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+
+	oldSigning, err := auth.LoadSigningKey("2024-01", writePEM(t, "old.pem", "PRIVATE KEY", rsaDER))
+	require.NoError(t, err)
+	old, err := auth.NewAsymmetricJWTService(oldSigning)
+	require.NoError(t, err)
+	oldToken, err := old.GenerateToken("64b7f0c2a1b2c3d4e5f60718", "kidus", "user", "64b7f0c2a1b2c3d4e5f60719", 0)
+	require.NoError(t, err)
+
+	// the new key signs, the old one only verifies
+	newSigning, err := auth.LoadSigningKey("2024-07", writePEM(t, "new.pem", "PRIVATE KEY", edDER))
+	require.NoError(t, err)
+	retired, err := auth.LoadVerificationKey("2024-01", writePEM(t, "old.pub", "PUBLIC KEY", rsaPublic))
+	require.NoError(t, err)
+	svc, err := auth.NewAsymmetricJWTService(newSigning, retired)
+	require.NoError(t, err)
+
+	claims, err := svc.ValidateToken(oldToken)
+	require.NoError(t, err)
+	assert.Equal(t, "kidus", claims.Username)
+	newToken, err := svc.GenerateToken("64b7f0c2a1b2c3d4e5f60718", "kidus", "user", "64b7f0c2a1b2c3d4e5f60719", 0)
+	require.NoError(t, err)
+	_, err = svc.ValidateToken(newToken)
+	assert.NoError(t, err)
+	_, err = old.ValidateToken(newToken)
+	assert.Error(t, err, "unknown kid")
+
+	jwks := svc.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "2024-01", jwks.Keys[0].Kid)
+	assert.Equal(t, "RS256", jwks.Keys[0].Alg)
+	assert.Equal(t, "2024-07", jwks.Keys[1].Kid)
+	assert.Equal(t, "EdDSA", jwks.Keys[1].Alg)
+	assert.Equal(t, "OKP", jwks.Keys[1].Kty)
+
+	// shared-secret tokens are refused once key pairs are in use
+	hsToken, err := auth.NewJWTService().GenerateToken("64b7f0c2a1b2c3d4e5f60718", "kidus", "admin", "64b7f0c2a1b2c3d4e5f60719", 0)
+	require.NoError(t, err)
+	_, err = svc.ValidateToken(hsToken)
+	assert.Error(t, err)
+	assert.Empty(t, auth.NewJWTService().JWKS().Keys)
+}
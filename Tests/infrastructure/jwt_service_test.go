@@ -11,7 +11,7 @@ func TestJWTGenerationValidation(t *testing.T) {
 	svc := auth.NewJWTService()
 	
 	
-	token, err := svc.GenerateToken("kidus", "admin")
+	token, err := svc.GenerateToken("64b7f0c2a1b2c3d4e5f60718", "kidus", "admin", "64b7f0c2a1b2c3d4e5f60719", 0)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, token)
 
@@ -19,6 +19,8 @@ func TestJWTGenerationValidation(t *testing.T) {
 	claims, err := svc.ValidateToken(token)
 	assert.NoError(t, err)
 	
+	assert.Equal(t, "64b7f0c2a1b2c3d4e5f60718", claims.UserID)
 	assert.Equal(t, "kidus", claims.Username)
 	assert.Equal(t, "admin", claims.Role)
+	assert.Equal(t, "64b7f0c2a1b2c3d4e5f60719", claims.TenantID)
 }
\ No newline at end of file
@@ -14,7 +14,7 @@ func TestAuthMiddlewareRejectsNoToken(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	
 	// FIX: Removed unused variable 'jwtSvc'
-	mw := auth.NewAuthMiddleware()
+	mw := auth.NewAuthMiddleware(auth.NewJWTService(), nil, nil)
 	r := gin.Default()
 
 	// NOTE: Ensure 'AuthRequired()' is implemented in your 'auth.AuthMiddleware' struct
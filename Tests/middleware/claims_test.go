@@ -0,0 +1,80 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"task_manager1/Infrastructure/auth"
+)
+
+func TestAuthMiddlewareValidatesClaims(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "test-secret")
+	svc := auth.NewJWTService()
+	svc.SetTokenTiming(auth.DefaultAccessTokenTTL, 5*time.Second)
+	var seen auth.Principal
+	r := gin.New()
+	r.GET("/protected", auth.NewAuthMiddleware(svc, nil, nil).Handle(), func(c *gin.Context) {
+		seen, _ = auth.PrincipalFrom(c)
+		c.String(http.StatusOK, "ok")
+	})
+	call := func(token string) int {
+		req, _ := http.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	sign := func(edit func(*auth.Claims), method jwt.SigningMethod) string {
+		now := time.Now()
+		claims := auth.Claims{
+			UserID:   "64b7f0c2a1b2c3d4e5f60718",
+			Username: "kidus",
+			Role:     "admin",
+			TenantID: "64b7f0c2a1b2c3d4e5f60719",
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    auth.DefaultIssuer,
+				Audience:  jwt.ClaimStrings{auth.DefaultAudience},
+				IssuedAt:  jwt.NewNumericDate(now),
+				NotBefore: jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			},
+		}
+		edit(&claims)
+		token, err := jwt.NewWithClaims(method, claims).SignedString([]byte("test-secret"))
+		require.NoError(t, err)
+		return token
+	}
+
+	token, err := svc.GenerateToken("64b7f0c2a1b2c3d4e5f60718", "kidus", "admin", "64b7f0c2a1b2c3d4e5f60719", 0)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, call(token))
+	assert.Equal(t, "kidus", seen.Username)
+	assert.True(t, seen.IsAdmin())
+	assert.Equal(t, "64b7f0c2a1b2c3d4e5f60719", seen.TenantID.Hex())
+
+	// clock skew within the leeway is tolerated
+	assert.Equal(t, http.StatusOK, call(sign(func(c *auth.Claims) {
+		c.NotBefore = jwt.NewNumericDate(time.Now().Add(3 * time.Second))
+	}, jwt.SigningMethodHS256)))
+
+	rejected := map[string]string{
+		"future nbf": sign(func(c *auth.Claims) {
+			c.NotBefore = jwt.NewNumericDate(time.Now().Add(time.Minute))
+		}, jwt.SigningMethodHS256),
+		"wrong issuer":   sign(func(c *auth.Claims) { c.Issuer = "someone-else" }, jwt.SigningMethodHS256),
+		"wrong audience": sign(func(c *auth.Claims) { c.Audience = jwt.ClaimStrings{"other-api"} }, jwt.SigningMethodHS256),
+		"no expiry":      sign(func(c *auth.Claims) { c.ExpiresAt = nil }, jwt.SigningMethodHS256),
+		"no tenant":      sign(func(c *auth.Claims) { c.TenantID = "" }, jwt.SigningMethodHS256),
+		"other hmac alg": sign(func(c *auth.Claims) {}, jwt.SigningMethodHS512),
+	}
+	for name, token := range rejected {
+		assert.Equal(t, http.StatusUnauthorized, call(token), name)
+	}
+}
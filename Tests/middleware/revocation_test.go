@@ -0,0 +1,73 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"task_manager1/Infrastructure/auth"
+)
+
+func TestAuthMiddlewareRejectsRevokedTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "test-secret")
+	svc := auth.NewJWTService()
+	store := auth.NewMemoryRevocationStore(time.Hour)
+	r := gin.New()
+	r.GET("/protected", auth.NewAuthMiddleware(svc, store, nil).Handle(), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	call := func(token string) int {
+		req, _ := http.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	const user = "64b7f0c2a1b2c3d4e5f60718"
+	first, err := svc.GenerateToken(user, "kidus", "user", "64b7f0c2a1b2c3d4e5f60719", 0)
+	assert.NoError(t, err)
+	second, err := svc.GenerateToken(user, "kidus", "user", "64b7f0c2a1b2c3d4e5f60719", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, call(first))
+
+	// logging out revokes only that token
+	claims, err := svc.ValidateToken(first)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, claims.ID)
+	assert.NoError(t, store.Revoke(context.Background(), claims.ID, claims.ExpiresAt.Time))
+	assert.Equal(t, http.StatusUnauthorized, call(first))
+	assert.Equal(t, http.StatusOK, call(second))
+
+	// revoking the user's sessions blocks every token issued before
+	claims, err = svc.ValidateToken(second)
+	assert.NoError(t, err)
+	assert.NoError(t, store.RevokeUser(context.Background(), user, time.Now()))
+	assert.Equal(t, http.StatusUnauthorized, call(second))
+}
+
+func TestRevokeUserRejectsTokensIssuedInTheSameSecond(t *testing.T) {
+	store := auth.NewMemoryRevocationStore(time.Hour)
+	const user = "64b7f0c2a1b2c3d4e5f60718"
+	at := time.Now().Truncate(time.Second).Add(700 * time.Millisecond)
+	assert.NoError(t, store.RevokeUser(context.Background(), user, at))
+
+	// iat only has second precision; a token stamped with the revocation's
+	// second may have been issued just before it, so it is revoked too
+	revoked, err := store.IsRevoked(context.Background(), "", user, at.Truncate(time.Second))
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = store.IsRevoked(context.Background(), "", user, at.Truncate(time.Second).Add(-time.Second))
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = store.IsRevoked(context.Background(), "", user, at.Truncate(time.Second).Add(time.Second))
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+}
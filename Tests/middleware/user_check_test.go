@@ -0,0 +1,60 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"task_manager1/Domain"
+	"task_manager1/Infrastructure/auth"
+	"task_manager1/Tests/mocks"
+)
+
+func TestAuthMiddlewareChecksLiveUserRecord(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "test-secret")
+	svc := auth.NewJWTService()
+	users := new(mocks.MockUserRepository)
+	mw := auth.NewAuthMiddleware(svc, nil, users)
+	mw.SetUserCacheTTL(0) // look the user up on every request
+
+	r := gin.New()
+	r.GET("/admin", mw.Handle(), mw.RequireAdmin(), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	call := func(token string) int {
+		req, _ := http.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	id, tenant := primitive.NewObjectID(), primitive.NewObjectID()
+	admin := Domain.User{ID: id, TenantID: tenant, Username: "kidus", Role: "admin", TokenVersion: 3}
+	token, err := svc.GenerateToken(id.Hex(), "kidus", "admin", tenant.Hex(), 3)
+	require.NoError(t, err)
+
+	users.On("FindByID", mock.Anything, id).Return(admin, nil).Once()
+	assert.Equal(t, http.StatusOK, call(token))
+
+	// a demotion bumps the version, so the admin token stops working
+	demoted := admin
+	demoted.Role, demoted.TokenVersion = "user", 4
+	users.On("FindByID", mock.Anything, id).Return(demoted, nil).Once()
+	assert.Equal(t, http.StatusUnauthorized, call(token))
+
+	disabled := admin
+	disabled.Disabled = true
+	users.On("FindByID", mock.Anything, id).Return(disabled, nil).Once()
+	assert.Equal(t, http.StatusUnauthorized, call(token))
+
+	users.On("FindByID", mock.Anything, id).Return(Domain.User{}, nil).Once()
+	assert.Equal(t, http.StatusUnauthorized, call(token))
+	users.AssertExpectations(t)
+}
@@ -0,0 +1,46 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"task_manager1/Domain"
+)
+
+type MockCommentRepository struct {
+	mock.Mock
+}
+
+func (m *MockCommentRepository) ListByTask(ctx context.Context, taskID primitive.ObjectID) ([]Domain.Comment, error) {
+	args := m.Called(ctx, taskID)
+	return args.Get(0).([]Domain.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) FindByID(ctx context.Context, id string) (Domain.Comment, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Domain.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) Create(ctx context.Context, c Domain.Comment) (Domain.Comment, error) {
+	args := m.Called(ctx, c)
+	if fn, ok := args.Get(0).(func(context.Context, Domain.Comment) Domain.Comment); ok {
+		return fn(ctx, c), args.Error(1)
+	}
+	return args.Get(0).(Domain.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) UpdateBody(ctx context.Context, id primitive.ObjectID, body string, mentions []string) (Domain.Comment, error) {
+	args := m.Called(ctx, id, body, mentions)
+	return args.Get(0).(Domain.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockCommentRepository) DeleteByTasks(ctx context.Context, taskIDs []primitive.ObjectID) (int64, error) {
+	args := m.Called(ctx, taskIDs)
+	return args.Get(0).(int64), args.Error(1)
+}
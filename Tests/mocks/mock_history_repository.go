@@ -0,0 +1,28 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"task_manager1/Domain"
+)
+
+type MockHistoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockHistoryRepository) Record(ctx context.Context, e Domain.TaskHistoryEntry) error {
+	args := m.Called(ctx, e)
+	return args.Error(0)
+}
+
+func (m *MockHistoryRepository) ListByTask(ctx context.Context, id string) ([]Domain.TaskHistoryEntry, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]Domain.TaskHistoryEntry), args.Error(1)
+}
+
+func (m *MockHistoryRepository) AsOf(ctx context.Context, id string, at time.Time) (Domain.TaskHistoryEntry, error) {
+	args := m.Called(ctx, id, at)
+	return args.Get(0).(Domain.TaskHistoryEntry), args.Error(1)
+}
@@ -0,0 +1,36 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"task_manager1/Domain"
+)
+
+type MockOrganizationRepository struct {
+	mock.Mock
+}
+
+func (m *MockOrganizationRepository) Create(ctx context.Context, o Domain.Organization) (Domain.Organization, error) {
+	args := m.Called(ctx, o)
+	if fn, ok := args.Get(0).(func(context.Context, Domain.Organization) Domain.Organization); ok {
+		return fn(ctx, o), args.Error(1)
+	}
+	return args.Get(0).(Domain.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) FindByID(ctx context.Context, id primitive.ObjectID) (Domain.Organization, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Domain.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) EnsureDefault(ctx context.Context, name string) (Domain.Organization, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).(Domain.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
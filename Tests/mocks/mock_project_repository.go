@@ -0,0 +1,61 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"task_manager1/Domain"
+)
+
+type MockProjectRepository struct {
+	mock.Mock
+}
+
+func (m *MockProjectRepository) List(ctx context.Context) ([]Domain.Project, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Domain.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) ListByMember(ctx context.Context, userID primitive.ObjectID) ([]Domain.Project, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]Domain.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) FindByID(ctx context.Context, id string) (Domain.Project, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Domain.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) Create(ctx context.Context, p Domain.Project) (Domain.Project, error) {
+	args := m.Called(ctx, p)
+	if fn, ok := args.Get(0).(func(context.Context, Domain.Project) Domain.Project); ok {
+		return fn(ctx, p), args.Error(1)
+	}
+	return args.Get(0).(Domain.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) Update(ctx context.Context, p Domain.Project) (Domain.Project, error) {
+	args := m.Called(ctx, p)
+	return args.Get(0).(Domain.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) SetMember(ctx context.Context, id primitive.ObjectID, member Domain.ProjectMember) (Domain.Project, error) {
+	args := m.Called(ctx, id, member)
+	return args.Get(0).(Domain.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) RemoveMember(ctx context.Context, id, userID primitive.ObjectID) (Domain.Project, error) {
+	args := m.Called(ctx, id, userID)
+	return args.Get(0).(Domain.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) EnsurePersonal(ctx context.Context, owner Domain.ProjectMember) (Domain.Project, error) {
+	args := m.Called(ctx, owner)
+	return args.Get(0).(Domain.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
@@ -0,0 +1,42 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"task_manager1/Domain"
+)
+
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, t Domain.RefreshToken) (Domain.RefreshToken, error) {
+	args := m.Called(ctx, t)
+	if fn, ok := args.Get(0).(func(context.Context, Domain.RefreshToken) Domain.RefreshToken); ok {
+		return fn(ctx, t), args.Error(1)
+	}
+	return args.Get(0).(Domain.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) FindByHash(ctx context.Context, hash string) (Domain.RefreshToken, error) {
+	args := m.Called(ctx, hash)
+	return args.Get(0).(Domain.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) MarkUsed(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error) {
+	args := m.Called(ctx, id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID primitive.ObjectID, at time.Time) (int64, error) {
+	args := m.Called(ctx, familyID, at)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeUser(ctx context.Context, userID primitive.ObjectID, at time.Time) (int64, error) {
+	args := m.Called(ctx, userID, at)
+	return args.Get(0).(int64), args.Error(1)
+}
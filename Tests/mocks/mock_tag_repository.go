@@ -0,0 +1,49 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"task_manager1/Domain"
+)
+
+type MockTagRepository struct {
+	mock.Mock
+}
+
+func (m *MockTagRepository) List(ctx context.Context) ([]Domain.Tag, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Domain.Tag), args.Error(1)
+}
+
+func (m *MockTagRepository) FindByID(ctx context.Context, id string) (Domain.Tag, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Domain.Tag), args.Error(1)
+}
+
+func (m *MockTagRepository) FindByNames(ctx context.Context, names []string) ([]Domain.Tag, error) {
+	args := m.Called(ctx, names)
+	return args.Get(0).([]Domain.Tag), args.Error(1)
+}
+
+func (m *MockTagRepository) Create(ctx context.Context, t Domain.Tag) (Domain.Tag, error) {
+	args := m.Called(ctx, t)
+	if fn, ok := args.Get(0).(func(context.Context, Domain.Tag) Domain.Tag); ok {
+		return fn(ctx, t), args.Error(1)
+	}
+	return args.Get(0).(Domain.Tag), args.Error(1)
+}
+
+func (m *MockTagRepository) Update(ctx context.Context, t Domain.Tag) (Domain.Tag, error) {
+	args := m.Called(ctx, t)
+	if fn, ok := args.Get(0).(func(context.Context, Domain.Tag) Domain.Tag); ok {
+		return fn(ctx, t), args.Error(1)
+	}
+	return args.Get(0).(Domain.Tag), args.Error(1)
+}
+
+func (m *MockTagRepository) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
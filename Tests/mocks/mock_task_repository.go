@@ -2,8 +2,10 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"task_manager1/Domain"
 )
 
@@ -13,12 +15,15 @@ type MockTaskRepository struct {
 
 func (m *MockTaskRepository) Create(ctx context.Context, t Domain.Task) (Domain.Task, error) {
 	args := m.Called(ctx, t)
+	if fn, ok := args.Get(0).(func(context.Context, Domain.Task) Domain.Task); ok {
+		return fn(ctx, t), args.Error(1)
+	}
 	return args.Get(0).(Domain.Task), args.Error(1)
 }
 
-func (m *MockTaskRepository) FindAll(ctx context.Context) ([]Domain.Task, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]Domain.Task), args.Error(1)
+func (m *MockTaskRepository) FindAll(ctx context.Context, f Domain.TaskFilter) (Domain.TaskPage, error) {
+	args := m.Called(ctx, f)
+	return args.Get(0).(Domain.TaskPage), args.Error(1)
 }
 
 func (m *MockTaskRepository) FindByID(ctx context.Context, id string) (Domain.Task, error) {
@@ -26,12 +31,61 @@ func (m *MockTaskRepository) FindByID(ctx context.Context, id string) (Domain.Ta
 	return args.Get(0).(Domain.Task), args.Error(1)
 }
 
-func (m *MockTaskRepository) Update(ctx context.Context, id string, t Domain.Task) (Domain.Task, error) {
-	args := m.Called(ctx, id, t)
+func (m *MockTaskRepository) Update(ctx context.Context, id string, changes Domain.TaskChanges) (Domain.Task, error) {
+	args := m.Called(ctx, id, changes)
+	if fn, ok := args.Get(0).(func(context.Context, string, Domain.TaskChanges) Domain.Task); ok {
+		return fn(ctx, id, changes), args.Error(1)
+	}
 	return args.Get(0).(Domain.Task), args.Error(1)
 }
 
-func (m *MockTaskRepository) Delete(ctx context.Context, id string) (bool, error) {
-    args := m.Called(ctx, id)
-    return args.Bool(0), args.Error(1)
+func (m *MockTaskRepository) Purge(ctx context.Context, deletedBefore time.Time) ([]Domain.Task, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Get(0).([]Domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) CountSubtasks(ctx context.Context, parentIDs []primitive.ObjectID) (map[primitive.ObjectID]Domain.SubtaskCounts, error) {
+	args := m.Called(ctx, parentIDs)
+	return args.Get(0).(map[primitive.ObjectID]Domain.SubtaskCounts), args.Error(1)
+}
+
+func (m *MockTaskRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]Domain.Task, error) {
+	args := m.Called(ctx, ids)
+	if fn, ok := args.Get(0).(func(context.Context, []primitive.ObjectID) []Domain.Task); ok {
+		return fn(ctx, ids), args.Error(1)
+	}
+	return args.Get(0).([]Domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) FindBlockedBy(ctx context.Context, blockerIDs []primitive.ObjectID) ([]Domain.Task, error) {
+	args := m.Called(ctx, blockerIDs)
+	return args.Get(0).([]Domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) FindSubtasks(ctx context.Context, parentIDs []primitive.ObjectID) ([]Domain.Task, error) {
+	args := m.Called(ctx, parentIDs)
+	return args.Get(0).([]Domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) FindRecurring(ctx context.Context, dueBefore time.Time, after primitive.ObjectID, limit int) ([]Domain.Task, error) {
+	args := m.Called(ctx, dueBefore, after, limit)
+	if fn, ok := args.Get(0).(func(context.Context, time.Time, primitive.ObjectID, int) []Domain.Task); ok {
+		return fn(ctx, dueBefore, after, limit), args.Error(1)
+	}
+	return args.Get(0).([]Domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) MarkNextCreated(ctx context.Context, id primitive.ObjectID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockTaskRepository) FindByTag(ctx context.Context, name string, limit int) ([]Domain.Task, error) {
+	args := m.Called(ctx, name, limit)
+	return args.Get(0).([]Domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) CountByProject(ctx context.Context, projectID primitive.ObjectID) (int64, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).(int64), args.Error(1)
 }
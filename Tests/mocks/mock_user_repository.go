@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"task_manager1/Domain"
 )
 
@@ -13,6 +14,9 @@ type MockUserRepository struct {
 
 func (m *MockUserRepository) Create(ctx context.Context, u Domain.User) (Domain.User, error) {
 	args := m.Called(ctx, u)
+	if fn, ok := args.Get(0).(func(context.Context, Domain.User) Domain.User); ok {
+		return fn(ctx, u), args.Error(1)
+	}
 	return args.Get(0).(Domain.User), args.Error(1)
 }
 
@@ -26,7 +30,22 @@ func (m *MockUserRepository) PromoteToAdmin(ctx context.Context, username string
 	return args.Get(0).(Domain.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Count(ctx context.Context) (int64, error) {
-	args := m.Called(ctx)
-	return args.Get(0).(int64), args.Error(1)
+func (m *MockUserRepository) SetTimezone(ctx context.Context, username, timezone string) (Domain.User, error) {
+	args := m.Called(ctx, username, timezone)
+	return args.Get(0).(Domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (Domain.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) DemoteToUser(ctx context.Context, username string) (Domain.User, error) {
+	args := m.Called(ctx, username)
+	return args.Get(0).(Domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) SetDisabled(ctx context.Context, username string, disabled bool) (Domain.User, error) {
+	args := m.Called(ctx, username, disabled)
+	return args.Get(0).(Domain.User), args.Error(1)
 }
@@ -0,0 +1,123 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"task_manager1/Repositories"
+)
+
+func TestMigrateDueDatesUsesOwnerZone(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("owner zones", func(mt *mtest.T) {
+		tokyo, la, gone := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+		user := func(id primitive.ObjectID, zone string) bson.D {
+			return mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: id}, {Key: "username", Value: zone}, {Key: "timezone", Value: zone}})
+		}
+		task := func(owner primitive.ObjectID, due string) bson.D {
+			return bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "owner_id", Value: owner}, {Key: "due_date", Value: due}}
+		}
+		updated := mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1})
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.tasks", mtest.FirstBatch,
+				task(tokyo, "2024-03-10"),
+				task(la, "2024-03-10"),
+				task(gone, "2024-03-10"),
+				task(tokyo, "2024-03-10T09:00:00"),
+				task(tokyo, "2024-03-10T09:00:00Z"),
+				task(tokyo, "next tuesday"),
+			),
+			user(tokyo, "Asia/Tokyo"), updated,
+			user(la, "America/Los_Angeles"), updated,
+			// an owner that no longer exists reads dates in UTC
+			mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch), updated,
+			// the owner's zone is looked up once
+			updated, updated, updated,
+		)
+
+		migrated, unparsed, err := Repositories.MigrateDueDates(context.Background(), mt.Coll, mt.DB.Collection("users"))
+		require.NoError(t, err)
+		assert.Equal(t, 5, migrated)
+		assert.Equal(t, 1, unparsed)
+
+		mt.FilterStartedEvents(func(e *event.CommandStartedEvent) bool { return e.CommandName == "update" })
+		updates := mt.GetAllStartedEvents()
+		require.Len(t, updates, 6)
+		want := []time.Time{
+			// date-only values end with that day where the owner lives
+			time.Date(2024, 3, 10, 14, 59, 59, 0, time.UTC),
+			time.Date(2024, 3, 11, 6, 59, 59, 0, time.UTC),
+			time.Date(2024, 3, 10, 23, 59, 59, 0, time.UTC),
+			time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC),
+			// an explicit offset wins over the owner's zone
+			time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC),
+		}
+		for i, due := range want {
+			got := updates[i].Command.Lookup("updates", "0", "u", "$set", "due_date").Time()
+			assert.True(t, due.Equal(got), "update %d: got %s, want %s", i, got, due)
+		}
+		legacy := updates[5].Command.Lookup("updates", "0", "u", "$set", "due_date_legacy").StringValue()
+		assert.Equal(t, "next tuesday", legacy)
+	})
+}
+
+func TestBackfillTenantsRerunUsesTheSameOrganization(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("rerun", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		orgs := Repositories.NewMongoOrganizationRepository(mt.DB.Collection("organizations"))
+		users := mt.DB.Collection("users")
+		ctx := context.Background()
+
+		org := primitive.NewObjectID()
+		count := func(n int) bson.D {
+			return mtest.CreateCursorResponse(0, "db.coll", mtest.FirstBatch, bson.D{{Key: "n", Value: n}})
+		}
+		found := mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{
+			{Key: "_id", Value: org}, {Key: "name", Value: "Default"}, {Key: "default", Value: true},
+		}})
+		updated := func(n int) bson.D {
+			return mtest.CreateSuccessResponse(bson.E{Key: "n", Value: n}, bson.E{Key: "nModified", Value: n})
+		}
+
+		// the first run fails after moving the tasks
+		mt.AddMockResponses(
+			count(2), found, updated(2),
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 6, Message: "host unreachable"}),
+		)
+		_, err := Repositories.BackfillTenants(ctx, orgs, "Default", mt.Coll, users)
+		require.Error(t, err)
+
+		mt.AddMockResponses(count(0), count(3), found, updated(3))
+		moved, err := Repositories.BackfillTenants(ctx, orgs, "Default", mt.Coll, users)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), moved)
+
+		mt.FilterStartedEvents(func(e *event.CommandStartedEvent) bool {
+			return e.CommandName == "findAndModify" || e.CommandName == "update"
+		})
+		started := mt.GetAllStartedEvents()
+		require.Len(t, started, 4)
+		for _, e := range started {
+			if e.CommandName == "findAndModify" {
+				// looked up, and only created when missing
+				assert.True(t, e.Command.Lookup("query", "default").Boolean())
+				assert.True(t, e.Command.Lookup("upsert").Boolean())
+				continue
+			}
+			tenant := e.Command.Lookup("updates", "0", "u", "$set", "tenant_id").ObjectID()
+			assert.Equal(t, org, tenant)
+		}
+	})
+}
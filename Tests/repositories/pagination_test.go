@@ -0,0 +1,145 @@
+package repositories_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"task_manager1/Domain"
+	"task_manager1/Repositories"
+)
+
+// newTaskRepo builds a task repository on the mock deployment. The first
+// reply answers the index creation done by the constructor.
+func newTaskRepo(mt *mtest.T) Repositories.TaskRepository {
+	mt.AddMockResponses(mtest.CreateSuccessResponse())
+	return Repositories.NewMongoTaskRepository(mt.Coll)
+}
+
+// canonical turns a BSON value into plain JSON values so documents compare
+// regardless of key order.
+func canonical(t *testing.T, v interface{}) interface{} {
+	raw, err := bson.MarshalExtJSON(bson.M{"v": v}, true, false)
+	require.NoError(t, err)
+	var out interface{}
+	require.NoError(t, json.Unmarshal(raw, &out))
+	return out
+}
+
+// rawCursor builds a cursor token by hand, as a client tampering with one would.
+func rawCursor(t *testing.T, sort string, values bson.A) string {
+	raw, err := bson.Marshal(bson.M{"s": sort, "v": values})
+	require.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func TestFindAllKeysetAfterCursor(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	ctx := Domain.AllTenants(context.Background())
+	due := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		desc bool
+		due  *time.Time
+		want func(id primitive.ObjectID) bson.M
+	}{
+		{
+			name: "asc after a value",
+			due:  &due,
+			want: func(id primitive.ObjectID) bson.M {
+				return bson.M{"$or": bson.A{
+					bson.M{"due_date": bson.M{"$gt": due}},
+					bson.M{"due_date": due, "_id": bson.M{"$gt": id}},
+				}}
+			},
+		},
+		{
+			name: "asc after null: every non-null value follows",
+			want: func(id primitive.ObjectID) bson.M {
+				return bson.M{"$or": bson.A{
+					bson.M{"due_date": bson.M{"$ne": nil}},
+					bson.M{"due_date": nil, "_id": bson.M{"$gt": id}},
+				}}
+			},
+		},
+		{
+			name: "desc after a value: smaller values and nulls follow",
+			desc: true,
+			due:  &due,
+			want: func(id primitive.ObjectID) bson.M {
+				return bson.M{"$or": bson.A{
+					bson.M{"due_date": bson.M{"$not": bson.M{"$gte": due}}},
+					bson.M{"due_date": due, "_id": bson.M{"$not": bson.M{"$gte": id}}},
+				}}
+			},
+		},
+		{
+			name: "desc after null: only later nulls follow",
+			desc: true,
+			want: func(id primitive.ObjectID) bson.M {
+				return bson.M{"$or": bson.A{
+					bson.M{"due_date": nil, "_id": bson.M{"$not": bson.M{"$gte": id}}},
+				}}
+			},
+		},
+	}
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			repo := newTaskRepo(mt)
+			last := bson.D{{Key: "_id", Value: primitive.NewObjectID()}}
+			if tt.due != nil {
+				last = append(last, bson.E{Key: "due_date", Value: *tt.due})
+			}
+			extra := bson.D{{Key: "_id", Value: primitive.NewObjectID()}}
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.tasks", mtest.FirstBatch, last, extra))
+
+			filter := Domain.TaskFilter{SortBy: Domain.SortByDueDate, SortDesc: tt.desc, Limit: 1}
+			page, err := repo.FindAll(ctx, filter)
+			require.NoError(t, err)
+			require.True(t, page.HasMore)
+
+			mt.ClearEvents()
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.tasks", mtest.FirstBatch))
+			filter.Cursor = page.NextCursor
+			_, err = repo.FindAll(ctx, filter)
+			require.NoError(t, err)
+
+			started := mt.GetStartedEvent()
+			require.NotNil(t, started)
+			after := started.Command.Lookup("filter", "$and", "1")
+			id := last[0].Value.(primitive.ObjectID)
+			assert.Equal(t, canonical(t, tt.want(id)), canonical(t, after))
+		})
+	}
+}
+
+func TestFindAllRejectsBadCursors(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	ctx := Domain.AllTenants(context.Background())
+	id := primitive.NewObjectID()
+	due := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	mt.Run("bad cursors", func(mt *mtest.T) {
+		repo := newTaskRepo(mt)
+		for name, token := range map[string]string{
+			"not base64":                 "%%%",
+			"not bson":                   "aGVsbG8",
+			"no values":                  rawCursor(t, Domain.SortByDueDate, bson.A{}),
+			"cursor shorter than sort":   rawCursor(t, Domain.SortByDueDate, bson.A{id}),
+			"cursor longer than sort":    rawCursor(t, Domain.SortByDueDate, bson.A{due, id, id}),
+			"cursor for another sort":    rawCursor(t, Domain.SortByCreatedAt, bson.A{due, id}),
+			"cursor for other direction": rawCursor(t, "-"+Domain.SortByDueDate, bson.A{due, id}),
+		} {
+			_, err := repo.FindAll(ctx, Domain.TaskFilter{SortBy: Domain.SortByDueDate, Cursor: token})
+			assert.ErrorIs(t, err, Domain.ErrInvalidCursor, name)
+		}
+	})
+}
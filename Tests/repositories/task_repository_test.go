@@ -0,0 +1,48 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"task_manager1/Domain"
+)
+
+func TestUpdateIfLiveSkipsTrashedTasks(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	ctx := Domain.AllTenants(context.Background())
+
+	mt.Run("already trashed", func(mt *mtest.T) {
+		repo := newTaskRepo(mt)
+		id := primitive.NewObjectID()
+		version := int64(3)
+		mt.ClearEvents()
+		// the task matches nothing, then is found outside the live filter
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: nil}),
+			mtest.CreateCursorResponse(0, "db.tasks", mtest.FirstBatch, bson.D{{Key: "n", Value: 0}}),
+		)
+
+		updated, err := repo.Update(ctx, id.Hex(), Domain.TaskChanges{
+			Set:       map[string]interface{}{"deleted_by": "kidus"},
+			IfVersion: &version,
+			IfLive:    true,
+		})
+		require.NoError(t, err)
+		assert.True(t, updated.ID.IsZero())
+
+		started := mt.GetAllStartedEvents()
+		require.Len(t, started, 2)
+		for _, e := range started {
+			query := e.Command.Lookup("query")
+			if e.CommandName == "aggregate" {
+				query = e.Command.Lookup("pipeline", "0", "$match")
+			}
+			assert.Equal(t, bson.TypeNull, query.Document().Lookup("deleted_at").Type, e.CommandName)
+		}
+	})
+}
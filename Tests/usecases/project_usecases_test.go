@@ -0,0 +1,34 @@
+package usecases_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"task_manager1/Domain"
+	"task_manager1/Tests/mocks"
+	"task_manager1/Usecases"
+)
+
+func TestProjectKeepsItsLastOwner(t *testing.T) {
+	tasks := new(mocks.MockTaskRepository)
+	users := new(mocks.MockUserRepository)
+	projects := new(mocks.MockProjectRepository)
+	uc := Usecases.NewProjectUsecase(projects, tasks, users)
+
+	owner := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	p := Domain.Project{ID: primitive.NewObjectID(), Members: []Domain.ProjectMember{{UserID: owner.UserID, Username: "kidus", Role: Domain.ProjectOwner}}}
+	projects.On("FindByID", mock.Anything, p.ID.Hex()).Return(p, nil)
+	users.On("FindByUsername", mock.Anything, "kidus").Return(Domain.User{ID: owner.UserID, Username: "kidus"}, nil)
+	tasks.On("CountByProject", mock.Anything, p.ID).Return(int64(2), nil)
+
+	_, err := uc.SetMember(context.Background(), owner, p.ID.Hex(), "kidus", Domain.ProjectViewer)
+	assert.ErrorIs(t, err, Domain.ErrLastProjectOwner)
+	_, err = uc.RemoveMember(context.Background(), owner, p.ID.Hex(), "kidus")
+	assert.ErrorIs(t, err, Domain.ErrLastProjectOwner)
+	_, err = uc.Delete(context.Background(), owner, p.ID.Hex())
+	assert.ErrorIs(t, err, Domain.ErrProjectNotEmpty)
+	projects.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
@@ -2,29 +2,1040 @@ package usecases_test
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"task_manager1/Domain"
+	"task_manager1/Infrastructure/storage"
 	"task_manager1/Tests/mocks"
 	"task_manager1/Usecases"
 )
 
 func TestCreateTask(t *testing.T) {
-	// FIX: The compiler error "InvalidIfaceAssign" happens because your 
-	// 'mocks.MockTaskRepository' is outdated. 
-	// You must update the mock's 'Delete' method signature to match the Interface.
-	repo := new(mocks.MockTaskRepository)
-	uc := Usecases.NewTaskUsecase(repo)
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
 
-	task := Domain.Task{Title: "Hello World"}
+	actor := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	task := Domain.TaskInput{Title: "Hello World"}
+	stored := Domain.Task{Title: "Hello World", Status: Domain.StatusTodo, Priority: Domain.PriorityMedium, PriorityRank: 2, CreatorID: actor.UserID, OwnerID: actor.UserID, ProjectID: personalProject, CreatedBy: "kidus"}
 
 	// Mock the Create method
-	repo.On("Create", mock.Anything, task).Return(task, nil)
+	repo.On("Create", mock.Anything, stored).Return(stored, nil)
 
-	created, err := uc.Create(context.Background(), task)
+	created, err := uc.Create(context.Background(), actor, task)
 
 	assert.NoError(t, err)
 	assert.Equal(t, "Hello World", created.Title)
-}
\ No newline at end of file
+	assert.Equal(t, actor.UserID, created.OwnerID)
+	assert.Equal(t, actor.UserID, created.CreatorID)
+}
+
+func TestListTasksScopesNonAdminToOwnTasks(t *testing.T) {
+	repo := newTaskRepo()
+	projects := new(mocks.MockProjectRepository)
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), projects)
+
+	actor := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	project := primitive.NewObjectID()
+	projects.On("ListByMember", mock.Anything, actor.UserID).Return([]Domain.Project{{ID: project}}, nil)
+	repo.On("FindAll", mock.Anything, Domain.TaskFilter{VisibleTo: actor.UserID, VisibleToName: "kidus", MemberOf: []primitive.ObjectID{project}}).Return(Domain.TaskPage{}, nil)
+
+	_, err := uc.List(context.Background(), actor, Domain.TaskFilter{})
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestListTasksAdminSeesEverything(t *testing.T) {
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	admin := Domain.Actor{UserID: primitive.NewObjectID(), Username: "root", Role: "admin"}
+	repo.On("FindAll", mock.Anything, Domain.TaskFilter{}).Return(Domain.TaskPage{}, nil)
+
+	_, err := uc.List(context.Background(), admin, Domain.TaskFilter{})
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestGetByIDHidesOtherUsersTasks(t *testing.T) {
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	id := primitive.NewObjectID()
+	owner := primitive.NewObjectID()
+	shared := primitive.NewObjectID()
+	task := Domain.Task{ID: id, Title: "secret", OwnerID: owner, SharedWith: []primitive.ObjectID{shared}}
+	repo.On("FindByID", mock.Anything, id.Hex()).Return(task, nil)
+
+	got, err := uc.GetByID(context.Background(), Domain.Actor{UserID: primitive.NewObjectID(), Role: "user"}, id.Hex())
+	assert.NoError(t, err)
+	assert.True(t, got.ID.IsZero())
+
+	got, err = uc.GetByID(context.Background(), Domain.Actor{UserID: shared, Role: "user"}, id.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, id, got.ID)
+}
+
+func TestUpdateTaskRejectsNonOwner(t *testing.T) {
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	id := primitive.NewObjectID()
+	other := primitive.NewObjectID()
+	task := Domain.Task{ID: id, Title: "shared", OwnerID: primitive.NewObjectID(), SharedWith: []primitive.ObjectID{other}}
+	repo.On("FindByID", mock.Anything, id.Hex()).Return(task, nil)
+
+	_, err := uc.Update(context.Background(), Domain.Actor{UserID: other, Role: "user"}, id.Hex(), Domain.TaskInput{Title: "mine now"}, nil)
+	assert.ErrorIs(t, err, Domain.ErrForbidden)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDeleteTaskAllowsOwnerAndAdmin(t *testing.T) {
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	id := primitive.NewObjectID()
+	owner := primitive.NewObjectID()
+	repo.On("FindByID", mock.Anything, id.Hex()).Return(Domain.Task{ID: id, OwnerID: owner}, nil)
+	trashes := mock.MatchedBy(func(c Domain.TaskChanges) bool {
+		_, ok := c.Set["deleted_at"]
+		return ok && c.IfVersion == nil && c.IfLive
+	})
+	repo.On("Update", mock.Anything, id.Hex(), trashes).Return(Domain.Task{ID: id, OwnerID: owner}, nil)
+
+	ok, err := uc.Delete(context.Background(), Domain.Actor{UserID: owner, Role: "user"}, id.Hex(), nil)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = uc.Delete(context.Background(), Domain.Actor{UserID: primitive.NewObjectID(), Role: "admin"}, id.Hex(), nil)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestAssignValidatesUsername(t *testing.T) {
+	repo := newTaskRepo()
+	users := new(mocks.MockUserRepository)
+	uc := Usecases.NewTaskUsecase(repo, users, anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	id := primitive.NewObjectID()
+	owner := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	task := Domain.Task{ID: id, OwnerID: owner.UserID}
+	repo.On("FindByID", mock.Anything, id.Hex()).Return(task, nil)
+	users.On("FindByUsername", mock.Anything, "ghost").Return(Domain.User{}, nil)
+	users.On("FindByUsername", mock.Anything, "abebe").Return(Domain.User{Username: "abebe"}, nil)
+	repo.On("Update", mock.Anything, id.Hex(), Domain.TaskChanges{
+		AddToSet:  map[string]interface{}{"assignees": "abebe"},
+		UpdatedBy: "kidus",
+	}).
+		Return(Domain.Task{ID: id, OwnerID: owner.UserID, Assignees: []string{"abebe"}}, nil)
+
+	_, err := uc.Assign(context.Background(), owner, id.Hex(), "ghost")
+	assert.ErrorIs(t, err, Domain.ErrUserNotFound)
+
+	updated, err := uc.Assign(context.Background(), owner, id.Hex(), "abebe")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"abebe"}, updated.Assignees)
+}
+
+func TestAssigneeCanSeeTask(t *testing.T) {
+	task := Domain.Task{OwnerID: primitive.NewObjectID(), Assignees: []string{"abebe"}}
+	assert.True(t, task.CanView(Domain.Actor{UserID: primitive.NewObjectID(), Username: "abebe", Role: "user"}, Domain.Project{}))
+	assert.False(t, task.CanModify(Domain.Actor{UserID: primitive.NewObjectID(), Username: "abebe", Role: "user"}, Domain.Project{}))
+}
+
+func TestListTasksRejectsInvalidQuery(t *testing.T) {
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+	admin := Domain.Actor{UserID: primitive.NewObjectID(), Role: "admin"}
+
+	_, err := uc.List(context.Background(), admin, Domain.TaskFilter{SortBy: "title"})
+	assert.ErrorIs(t, err, Domain.ErrInvalidQuery)
+
+	_, err = uc.List(context.Background(), admin, Domain.TaskFilter{Limit: Domain.MaxPageLimit + 1})
+	assert.ErrorIs(t, err, Domain.ErrInvalidQuery)
+
+	after := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, err = uc.List(context.Background(), admin, Domain.TaskFilter{DueAfter: &after, DueBefore: &before})
+	assert.ErrorIs(t, err, Domain.ErrInvalidQuery)
+	repo.AssertNotCalled(t, "FindAll", mock.Anything, mock.Anything)
+}
+
+func TestCreateTaskReadsDateOnlyDueDateInUserTimezone(t *testing.T) {
+	repo := newTaskRepo()
+	users := new(mocks.MockUserRepository)
+	uc := Usecases.NewTaskUsecase(repo, users, anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	actor := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	users.On("FindByUsername", mock.Anything, "kidus").Return(Domain.User{Username: "kidus", Timezone: "Africa/Addis_Ababa"}, nil)
+	repo.On("Create", mock.Anything, mock.Anything).Return(func(_ context.Context, task Domain.Task) Domain.Task {
+		return task
+	}, nil)
+
+	created, err := uc.Create(context.Background(), actor, Domain.TaskInput{Title: "report", DueDate: "2025-03-10"})
+	assert.NoError(t, err)
+	// 23:59:59 in Addis Ababa (UTC+3)
+	assert.Equal(t, time.Date(2025, 3, 10, 20, 59, 59, 0, time.UTC), *created.DueDate)
+}
+
+func TestCreateTaskRejectsInvalidDueDate(t *testing.T) {
+	repo := newTaskRepo()
+	users := new(mocks.MockUserRepository)
+	uc := Usecases.NewTaskUsecase(repo, users, anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	actor := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	users.On("FindByUsername", mock.Anything, "kidus").Return(Domain.User{Username: "kidus"}, nil)
+
+	for _, due := range []string{"tomorrow", "2024-13-45"} {
+		_, err := uc.Create(context.Background(), actor, Domain.TaskInput{Title: "x", DueDate: due})
+		assert.ErrorIs(t, err, Domain.ErrInvalidDueDate)
+	}
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestParseDueDateAcceptsRFC3339(t *testing.T) {
+	ts, err := Usecases.ParseDueDate("2025-03-10T09:30:00+03:00", time.UTC, true)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 3, 10, 6, 30, 0, 0, time.UTC), ts)
+}
+
+func TestCreateTaskDefaultsToInitialStatus(t *testing.T) {
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	actor := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	repo.On("Create", mock.Anything, mock.Anything).Return(func(_ context.Context, task Domain.Task) Domain.Task {
+		return task
+	}, nil)
+
+	created, err := uc.Create(context.Background(), actor, Domain.TaskInput{Title: "x"})
+	assert.NoError(t, err)
+	assert.Equal(t, Domain.StatusTodo, created.Status)
+
+	_, err = uc.Create(context.Background(), actor, Domain.TaskInput{Title: "x", Status: "someday"})
+	assert.ErrorIs(t, err, Domain.ErrUnknownStatus)
+
+	// later states are only reached through transitions
+	_, err = uc.Create(context.Background(), actor, Domain.TaskInput{Title: "x", Status: Domain.StatusDone})
+	var transition *Domain.TransitionError
+	assert.ErrorAs(t, err, &transition)
+	assert.Equal(t, []string{Domain.StatusTodo}, transition.Allowed)
+}
+
+func TestUpdateTaskRejectsIllegalTransition(t *testing.T) {
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	id := primitive.NewObjectID()
+	owner := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	repo.On("FindByID", mock.Anything, id.Hex()).Return(Domain.Task{ID: id, OwnerID: owner.UserID, Status: Domain.StatusTodo}, nil)
+
+	_, err := uc.Update(context.Background(), owner, id.Hex(), Domain.TaskInput{Title: "x", Status: Domain.StatusDone}, nil)
+	var transition *Domain.TransitionError
+	assert.ErrorAs(t, err, &transition)
+	assert.Equal(t, []string{Domain.StatusInProgress}, transition.Allowed)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateTaskIsFullReplace(t *testing.T) {
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	id := primitive.NewObjectID()
+	owner := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	repo.On("FindByID", mock.Anything, id.Hex()).Return(Domain.Task{ID: id, OwnerID: owner.UserID, Title: "old", Description: "old text", Version: 3}, nil)
+	version := int64(3)
+	expected := Domain.TaskChanges{
+		Set:       map[string]interface{}{"title": "new", "priority": Domain.PriorityMedium, "priority_rank": 2},
+		Unset:     []string{"description", "due_date", "shared_with", "tags", "parent_id", "recurrence"},
+		IfVersion: &version,
+		UpdatedBy: "kidus",
+	}
+	repo.On("Update", mock.Anything, id.Hex(), expected).Return(Domain.Task{ID: id, Title: "new"}, nil)
+
+	_, err := uc.Update(context.Background(), owner, id.Hex(), Domain.TaskInput{Title: "new"}, nil)
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+
+	_, err = uc.Update(context.Background(), owner, id.Hex(), Domain.TaskInput{}, nil)
+	assert.ErrorIs(t, err, Domain.ErrTitleRequired)
+}
+
+func TestPatchTaskUnsetsNullFields(t *testing.T) {
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	id := primitive.NewObjectID()
+	owner := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	repo.On("FindByID", mock.Anything, id.Hex()).Return(Domain.Task{ID: id, OwnerID: owner.UserID, Title: "t", Description: "d"}, nil)
+	version := int64(0)
+	expected := Domain.TaskChanges{Set: map[string]interface{}{}, Unset: []string{"description"}, IfVersion: &version, UpdatedBy: "kidus"}
+	repo.On("Update", mock.Anything, id.Hex(), expected).Return(Domain.Task{ID: id, Title: "t"}, nil)
+
+	var patch map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal([]byte(`{"description": null}`), &patch))
+	_, err := uc.Patch(context.Background(), owner, id.Hex(), patch, nil)
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+
+	patch = nil
+	assert.NoError(t, json.Unmarshal([]byte(`{"title": null}`), &patch))
+	_, err = uc.Patch(context.Background(), owner, id.Hex(), patch, nil)
+	assert.ErrorIs(t, err, Domain.ErrTitleRequired)
+
+	patch = nil
+	assert.NoError(t, json.Unmarshal([]byte(`{"owner_id": "x"}`), &patch))
+	_, err = uc.Patch(context.Background(), owner, id.Hex(), patch, nil)
+	assert.ErrorIs(t, err, Domain.ErrInvalidPatch)
+
+	// an empty patch is a no-op that returns the current task
+	task, err := uc.Patch(context.Background(), owner, id.Hex(), map[string]json.RawMessage{}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "d", task.Description)
+	repo.AssertNumberOfCalls(t, "Update", 1)
+}
+
+func TestUpdateTaskRejectsStaleVersion(t *testing.T) {
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	id := primitive.NewObjectID()
+	owner := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	repo.On("FindByID", mock.Anything, id.Hex()).Return(Domain.Task{ID: id, OwnerID: owner.UserID, Title: "t", Version: 5}, nil)
+
+	stale := int64(4)
+	_, err := uc.Update(context.Background(), owner, id.Hex(), Domain.TaskInput{Title: "mine"}, &stale)
+	assert.ErrorIs(t, err, Domain.ErrVersionMismatch)
+
+	_, err = uc.Delete(context.Background(), owner, id.Hex(), &stale)
+	assert.ErrorIs(t, err, Domain.ErrVersionMismatch)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// newTaskRepo returns a task repository mock whose tasks have no subtasks
+// unless a test says otherwise.
+func newTaskRepo() *mocks.MockTaskRepository {
+	repo := new(mocks.MockTaskRepository)
+	repo.On("CountSubtasks", mock.Anything, mock.Anything).Return(map[primitive.ObjectID]Domain.SubtaskCounts{}, nil).Maybe()
+	return repo
+}
+
+// personalProject is the personal project anyProjects hands out.
+var personalProject = primitive.NewObjectID()
+
+// anyProjects puts new tasks into personalProject and finds no memberships,
+// for tests that only deal with tasks outside of shared projects.
+func anyProjects() *mocks.MockProjectRepository {
+	p := new(mocks.MockProjectRepository)
+	p.On("EnsurePersonal", mock.Anything, mock.Anything).Return(Domain.Project{ID: personalProject}, nil).Maybe()
+	p.On("ListByMember", mock.Anything, mock.Anything).Return([]Domain.Project{}, nil).Maybe()
+	return p
+}
+
+// anyHistory accepts every history write for tests that do not inspect it.
+func anyHistory() *mocks.MockHistoryRepository {
+	h := new(mocks.MockHistoryRepository)
+	h.On("Record", mock.Anything, mock.Anything).Return(nil).Maybe()
+	return h
+}
+
+func TestCreateTaskSucceedsWhenHistoryFails(t *testing.T) {
+	repo := newTaskRepo()
+	history := new(mocks.MockHistoryRepository)
+	history.On("Record", mock.Anything, mock.Anything).Return(errors.New("history down"))
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), history, new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	id := primitive.NewObjectID()
+	repo.On("Create", mock.Anything, mock.Anything).Return(func(_ context.Context, task Domain.Task) Domain.Task {
+		task.ID = id
+		return task
+	}, nil)
+
+	// the task is already stored, so the request must not report a failure
+	created, err := uc.Create(context.Background(), Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}, Domain.TaskInput{Title: "x"})
+	assert.NoError(t, err)
+	assert.Equal(t, id, created.ID)
+	history.AssertExpectations(t)
+}
+
+func TestUpdateTaskRecordsChangedFields(t *testing.T) {
+	repo := newTaskRepo()
+	history := new(mocks.MockHistoryRepository)
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), history, new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	id := primitive.NewObjectID()
+	owner := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	before := Domain.Task{ID: id, OwnerID: owner.UserID, Title: "old", Description: "d", Status: Domain.StatusTodo, Version: 1}
+	after := Domain.Task{ID: id, OwnerID: owner.UserID, Title: "new", Status: Domain.StatusTodo, Version: 2}
+	repo.On("FindByID", mock.Anything, id.Hex()).Return(before, nil)
+	repo.On("Update", mock.Anything, id.Hex(), mock.Anything).Return(after, nil)
+
+	var entry Domain.TaskHistoryEntry
+	history.On("Record", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		entry = args.Get(1).(Domain.TaskHistoryEntry)
+	}).Return(nil)
+
+	_, err := uc.Update(context.Background(), owner, id.Hex(), Domain.TaskInput{Title: "new"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, Domain.HistoryUpdated, entry.Action)
+	assert.Equal(t, "kidus", entry.Actor)
+	assert.Equal(t, int64(2), entry.Version)
+	assert.Equal(t, []Domain.FieldChange{
+		{Field: "description", Before: "d", After: nil},
+		{Field: "title", Before: "old", After: "new"},
+	}, entry.Changes)
+}
+
+func TestHistoryHidesOtherUsersTasks(t *testing.T) {
+	repo := newTaskRepo()
+	history := new(mocks.MockHistoryRepository)
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), history, new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	id := primitive.NewObjectID()
+	owner := primitive.NewObjectID()
+	stranger := Domain.Actor{UserID: primitive.NewObjectID(), Username: "eve", Role: "user"}
+	snapshot := Domain.Task{ID: id, OwnerID: owner, Title: "t"}
+	history.On("ListByTask", mock.Anything, id.Hex()).Return([]Domain.TaskHistoryEntry{{TaskID: id, Action: Domain.HistoryDeleted, Snapshot: snapshot}}, nil)
+	repo.On("FindByID", mock.Anything, id.Hex()).Return(Domain.Task{}, nil)
+
+	entries, err := uc.History(context.Background(), stranger, id.Hex())
+	assert.NoError(t, err)
+	assert.Nil(t, entries)
+
+	entries, err = uc.History(context.Background(), Domain.Actor{UserID: owner, Username: "kidus", Role: "user"}, id.Hex())
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestTrashedTaskIsHiddenUntilRestored(t *testing.T) {
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	id := primitive.NewObjectID()
+	owner := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	deletedAt := time.Now()
+	trashed := Domain.Task{ID: id, OwnerID: owner.UserID, Title: "t", Version: 2, DeletedAt: &deletedAt, DeletedBy: "kidus"}
+	repo.On("FindByID", mock.Anything, id.Hex()).Return(trashed, nil)
+	version := int64(2)
+	repo.On("Update", mock.Anything, id.Hex(), Domain.TaskChanges{
+		Unset:     []string{"deleted_at", "deleted_by"},
+		IfVersion: &version,
+		UpdatedBy: "kidus",
+	}).Return(Domain.Task{ID: id, OwnerID: owner.UserID, Title: "t", Version: 3}, nil)
+
+	got, err := uc.GetByID(context.Background(), owner, id.Hex())
+	assert.NoError(t, err)
+	assert.True(t, got.ID.IsZero())
+
+	_, err = uc.Restore(context.Background(), Domain.Actor{UserID: primitive.NewObjectID(), Username: "eve", Role: "user"}, id.Hex())
+	assert.NoError(t, err)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+
+	restored, err := uc.Restore(context.Background(), owner, id.Hex())
+	assert.NoError(t, err)
+	assert.False(t, restored.IsDeleted())
+}
+
+func TestRestoreRejectsTaskNotInTrash(t *testing.T) {
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	id := primitive.NewObjectID()
+	owner := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	repo.On("FindByID", mock.Anything, id.Hex()).Return(Domain.Task{ID: id, OwnerID: owner.UserID}, nil)
+
+	_, err := uc.Restore(context.Background(), owner, id.Hex())
+	assert.ErrorIs(t, err, Domain.ErrNotInTrash)
+}
+
+func TestPurgeTrashRecordsPurgedTasks(t *testing.T) {
+	repo := newTaskRepo()
+	history := new(mocks.MockHistoryRepository)
+	comments := new(mocks.MockCommentRepository)
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), history, new(mocks.MockTagRepository), comments, new(mocks.MockBlobStore), anyProjects())
+
+	id := primitive.NewObjectID()
+	repo.On("Purge", mock.Anything, mock.Anything).Return([]Domain.Task{{ID: id, Title: "old"}}, nil)
+	comments.On("DeleteByTasks", mock.Anything, []primitive.ObjectID{id}).Return(int64(2), nil)
+	history.On("Record", mock.Anything, mock.MatchedBy(func(e Domain.TaskHistoryEntry) bool {
+		return e.TaskID == id && e.Action == Domain.HistoryPurged && e.Snapshot.Title == "old"
+	})).Return(nil)
+
+	n, err := uc.PurgeTrash(context.Background(), 24*time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	history.AssertExpectations(t)
+	comments.AssertExpectations(t)
+}
+
+func TestUpdateTaskRejectsParentCycle(t *testing.T) {
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	owner := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	parent := Domain.Task{ID: primitive.NewObjectID(), OwnerID: owner.UserID, Title: "parent"}
+	child := Domain.Task{ID: primitive.NewObjectID(), OwnerID: owner.UserID, Title: "child", ParentID: parent.ID}
+	repo.On("FindByID", mock.Anything, parent.ID.Hex()).Return(parent, nil)
+	repo.On("FindByID", mock.Anything, child.ID.Hex()).Return(child, nil)
+
+	_, err := uc.Update(context.Background(), owner, parent.ID.Hex(), Domain.TaskInput{Title: "parent", ParentID: child.ID}, nil)
+	assert.ErrorIs(t, err, Domain.ErrInvalidParent)
+
+	_, err = uc.Update(context.Background(), owner, parent.ID.Hex(), Domain.TaskInput{Title: "parent", ParentID: parent.ID}, nil)
+	assert.ErrorIs(t, err, Domain.ErrInvalidParent)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateTaskRejectsReparentingDeepSubtree(t *testing.T) {
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	owner := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	// a chain eight levels deep to move tasks under
+	var target Domain.Task
+	for i := 0; i < 8; i++ {
+		task := Domain.Task{ID: primitive.NewObjectID(), OwnerID: owner.UserID, Title: "level", ParentID: target.ID}
+		repo.On("FindByID", mock.Anything, task.ID.Hex()).Return(task, nil)
+		target = task
+	}
+	// subtree hangs levels of subtasks below a fresh root task
+	subtree := func(levels int) Domain.Task {
+		root := Domain.Task{ID: primitive.NewObjectID(), OwnerID: owner.UserID, Title: "moved"}
+		repo.On("FindByID", mock.Anything, root.ID.Hex()).Return(root, nil)
+		parent := root.ID
+		for i := 0; i < levels; i++ {
+			child := Domain.Task{ID: primitive.NewObjectID(), ParentID: parent}
+			repo.On("FindSubtasks", mock.Anything, []primitive.ObjectID{parent}).Return([]Domain.Task{child}, nil)
+			parent = child.ID
+		}
+		repo.On("FindSubtasks", mock.Anything, []primitive.ObjectID{parent}).Return([]Domain.Task{}, nil).Maybe()
+		return root
+	}
+
+	deep := subtree(5)
+	_, err := uc.Update(context.Background(), owner, deep.ID.Hex(), Domain.TaskInput{Title: "moved", ParentID: target.ID}, nil)
+	assert.ErrorIs(t, err, Domain.ErrInvalidParent)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+
+	shallow := subtree(1)
+	repo.On("Update", mock.Anything, shallow.ID.Hex(), mock.Anything).Return(shallow, nil)
+	repo.On("FindBlockedBy", mock.Anything, mock.Anything).Return([]Domain.Task{}, nil).Maybe()
+	_, err = uc.Update(context.Background(), owner, shallow.ID.Hex(), Domain.TaskInput{Title: "moved", ParentID: target.ID}, nil)
+	assert.NoError(t, err)
+}
+
+func TestChecklistToggleAndReorder(t *testing.T) {
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	owner := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	first := Domain.ChecklistItem{ID: primitive.NewObjectID(), Text: "one"}
+	second := Domain.ChecklistItem{ID: primitive.NewObjectID(), Text: "two"}
+	task := Domain.Task{ID: primitive.NewObjectID(), OwnerID: owner.UserID, Title: "t", Version: 1, Checklist: []Domain.ChecklistItem{first, second}}
+	repo.On("FindByID", mock.Anything, task.ID.Hex()).Return(task, nil)
+	repo.On("Update", mock.Anything, task.ID.Hex(), mock.Anything).Return(func(_ context.Context, _ string, c Domain.TaskChanges) Domain.Task {
+		updated := task
+		updated.Checklist = c.Set["checklist"].([]Domain.ChecklistItem)
+		return updated
+	}, nil)
+
+	done := true
+	updated, err := uc.UpdateChecklistItem(context.Background(), owner, task.ID.Hex(), second.ID.Hex(), nil, &done, nil)
+	assert.NoError(t, err)
+	assert.True(t, updated.Checklist[1].Done)
+	assert.Equal(t, 50, *updated.Progress())
+	assert.False(t, task.Checklist[1].Done, "stored task must not be modified in place")
+
+	updated, err = uc.ReorderChecklist(context.Background(), owner, task.ID.Hex(), []string{second.ID.Hex(), first.ID.Hex()}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []Domain.ChecklistItem{second, first}, updated.Checklist)
+
+	_, err = uc.ReorderChecklist(context.Background(), owner, task.ID.Hex(), []string{first.ID.Hex(), first.ID.Hex()}, nil)
+	assert.ErrorIs(t, err, Domain.ErrInvalidChecklist)
+
+	_, err = uc.UpdateChecklistItem(context.Background(), owner, task.ID.Hex(), primitive.NewObjectID().Hex(), nil, &done, nil)
+	assert.ErrorIs(t, err, Domain.ErrChecklistItemNotFound)
+}
+
+func TestAddDependencyRejectsCycle(t *testing.T) {
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	owner := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	a := Domain.Task{ID: primitive.NewObjectID(), OwnerID: owner.UserID, Title: "a"}
+	b := Domain.Task{ID: primitive.NewObjectID(), OwnerID: owner.UserID, Title: "b", BlockedBy: []primitive.ObjectID{a.ID}}
+	c := Domain.Task{ID: primitive.NewObjectID(), OwnerID: owner.UserID, Title: "c", BlockedBy: []primitive.ObjectID{b.ID}}
+	repo.On("FindByID", mock.Anything, a.ID.Hex()).Return(a, nil)
+	repo.On("FindByID", mock.Anything, c.ID.Hex()).Return(c, nil)
+	repo.On("FindByIDs", mock.Anything, []primitive.ObjectID{b.ID}).Return([]Domain.Task{b}, nil)
+
+	// c waits on b which waits on a, so a cannot wait on c
+	_, err := uc.AddDependency(context.Background(), owner, a.ID.Hex(), c.ID.Hex())
+	assert.ErrorIs(t, err, Domain.ErrDependencyCycle)
+
+	_, err = uc.AddDependency(context.Background(), owner, a.ID.Hex(), a.ID.Hex())
+	assert.ErrorIs(t, err, Domain.ErrInvalidDependency)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAddDependencyUndoesLinkThatRacedIntoCycle(t *testing.T) {
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	owner := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	a := Domain.Task{ID: primitive.NewObjectID(), OwnerID: owner.UserID, Title: "a", Version: 1}
+	b := Domain.Task{ID: primitive.NewObjectID(), OwnerID: owner.UserID, Title: "b"}
+	repo.On("FindByID", mock.Anything, a.ID.Hex()).Return(a, nil)
+	repo.On("FindByID", mock.Anything, b.ID.Hex()).Return(b, nil)
+	// b was linked to a after it was read, so a waiting on b closes a cycle
+	raced := b
+	raced.BlockedBy = []primitive.ObjectID{a.ID}
+	repo.On("FindByIDs", mock.Anything, []primitive.ObjectID{b.ID}).Return([]Domain.Task{raced}, nil)
+	adds := mock.MatchedBy(func(c Domain.TaskChanges) bool { return c.AddToSet["blocked_by"] == b.ID })
+	linked := a
+	linked.BlockedBy = []primitive.ObjectID{b.ID}
+	repo.On("Update", mock.Anything, a.ID.Hex(), adds).Return(linked, nil).Once()
+	pulls := mock.MatchedBy(func(c Domain.TaskChanges) bool { return c.Pull["blocked_by"] == b.ID })
+	repo.On("Update", mock.Anything, a.ID.Hex(), pulls).Return(a, nil).Once()
+
+	_, err := uc.AddDependency(context.Background(), owner, a.ID.Hex(), b.ID.Hex())
+	assert.ErrorIs(t, err, Domain.ErrDependencyCycle)
+	repo.AssertExpectations(t)
+}
+
+func TestBlockedTaskCannotMoveToDone(t *testing.T) {
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	owner := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	blocker := Domain.Task{ID: primitive.NewObjectID(), OwnerID: owner.UserID, Title: "first", Status: Domain.StatusInProgress}
+	task := Domain.Task{ID: primitive.NewObjectID(), OwnerID: owner.UserID, Title: "second", Status: Domain.StatusReview, BlockedBy: []primitive.ObjectID{blocker.ID}}
+	repo.On("FindByID", mock.Anything, task.ID.Hex()).Return(task, nil)
+	repo.On("FindByIDs", mock.Anything, []primitive.ObjectID{blocker.ID}).Return([]Domain.Task{blocker}, nil)
+
+	got, err := uc.GetByID(context.Background(), owner, task.ID.Hex())
+	assert.NoError(t, err)
+	assert.True(t, got.Blocked)
+
+	_, err = uc.Update(context.Background(), owner, task.ID.Hex(), Domain.TaskInput{Title: "second", Status: Domain.StatusDone}, nil)
+	assert.ErrorIs(t, err, Domain.ErrTaskBlocked)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGraphFollowsDependenciesBothWays(t *testing.T) {
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	owner := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	up := Domain.Task{ID: primitive.NewObjectID(), OwnerID: owner.UserID, Title: "up", Status: Domain.StatusDone}
+	root := Domain.Task{ID: primitive.NewObjectID(), OwnerID: owner.UserID, Title: "root", BlockedBy: []primitive.ObjectID{up.ID}}
+	down := Domain.Task{ID: primitive.NewObjectID(), OwnerID: owner.UserID, Title: "down", BlockedBy: []primitive.ObjectID{root.ID}}
+	hidden := Domain.Task{ID: primitive.NewObjectID(), OwnerID: primitive.NewObjectID(), Title: "hidden", BlockedBy: []primitive.ObjectID{root.ID}}
+	repo.On("FindByID", mock.Anything, root.ID.Hex()).Return(root, nil)
+	repo.On("FindByIDs", mock.Anything, mock.Anything).Return(func(_ context.Context, ids []primitive.ObjectID) []Domain.Task {
+		found := []Domain.Task{}
+		for _, id := range ids {
+			for _, task := range []Domain.Task{up, root, down, hidden} {
+				if task.ID == id {
+					found = append(found, task)
+				}
+			}
+		}
+		return found
+	}, nil)
+	repo.On("FindBlockedBy", mock.Anything, []primitive.ObjectID{root.ID}).Return([]Domain.Task{down, hidden}, nil)
+	repo.On("FindBlockedBy", mock.Anything, []primitive.ObjectID{down.ID}).Return([]Domain.Task{}, nil)
+
+	g, err := uc.Graph(context.Background(), owner, root.ID.Hex())
+	assert.NoError(t, err)
+	assert.Len(t, g.Nodes, 3)
+	assert.ElementsMatch(t, []Domain.DependencyEdge{{From: up.ID, To: root.ID}, {From: root.ID, To: down.ID}}, g.Edges)
+	for _, n := range g.Nodes {
+		assert.Equal(t, n.ID == down.ID, n.Blocked, n.Title)
+	}
+}
+
+func TestCreateRecurringTaskNeedsDueDate(t *testing.T) {
+	repo := newTaskRepo()
+	users := new(mocks.MockUserRepository)
+	uc := Usecases.NewTaskUsecase(repo, users, anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	actor := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	users.On("FindByUsername", mock.Anything, "kidus").Return(Domain.User{Username: "kidus", Timezone: "Africa/Addis_Ababa"}, nil)
+	repo.On("Create", mock.Anything, mock.Anything).Return(func(_ context.Context, task Domain.Task) Domain.Task { return task }, nil)
+
+	_, err := uc.Create(context.Background(), actor, Domain.TaskInput{Title: "chores", Recurrence: "FREQ=WEEKLY;BYDAY=MO"})
+	assert.ErrorIs(t, err, Domain.ErrInvalidRecurrence)
+
+	_, err = uc.Create(context.Background(), actor, Domain.TaskInput{Title: "chores", DueDate: "2025-01-06", Recurrence: "FREQ=SECONDLY"})
+	assert.ErrorIs(t, err, Domain.ErrInvalidRecurrence)
+
+	created, err := uc.Create(context.Background(), actor, Domain.TaskInput{Title: "chores", DueDate: "2025-01-06", Recurrence: "RRULE:freq=weekly;byday=MO"})
+	assert.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO", created.Recurrence.Rule)
+	assert.Equal(t, "Africa/Addis_Ababa", created.Recurrence.Timezone)
+	assert.Equal(t, 1, created.Recurrence.Index)
+	assert.False(t, created.Recurrence.SeriesID.IsZero())
+}
+
+func TestCompletingRecurringTaskCreatesNextOccurrence(t *testing.T) {
+	repo := newTaskRepo()
+	users := new(mocks.MockUserRepository)
+	uc := Usecases.NewTaskUsecase(repo, users, anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	owner := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	users.On("FindByUsername", mock.Anything, "kidus").Return(Domain.User{Username: "kidus"}, nil)
+	due := time.Date(2025, 1, 6, 20, 59, 59, 0, time.UTC)
+	rec := &Domain.TaskRecurrence{Rule: "FREQ=WEEKLY;BYDAY=MO,WE", Start: due, Timezone: "UTC", SeriesID: primitive.NewObjectID(), Index: 1}
+	task := Domain.Task{
+		ID: primitive.NewObjectID(), OwnerID: owner.UserID, Title: "ops", Status: Domain.StatusReview, DueDate: &due, Recurrence: rec,
+		Checklist: []Domain.ChecklistItem{{ID: primitive.NewObjectID(), Text: "rotate logs", Done: true}},
+	}
+	done := task
+	done.Status = Domain.StatusDone
+	repo.On("FindByID", mock.Anything, task.ID.Hex()).Return(task, nil)
+	repo.On("Update", mock.Anything, task.ID.Hex(), mock.Anything).Return(done, nil)
+	repo.On("MarkNextCreated", mock.Anything, task.ID).Return(nil)
+
+	var next Domain.Task
+	repo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		next = args.Get(1).(Domain.Task)
+	}).Return(func(_ context.Context, task Domain.Task) Domain.Task { return task }, nil)
+
+	_, err := uc.Update(context.Background(), owner, task.ID.Hex(), Domain.TaskInput{Title: "ops", DueDate: due.Format(time.RFC3339), Status: Domain.StatusDone, Recurrence: rec.Rule}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 8, 20, 59, 59, 0, time.UTC), *next.DueDate)
+	assert.Equal(t, Domain.StatusTodo, next.Status)
+	assert.Equal(t, 2, next.Recurrence.Index)
+	assert.Equal(t, rec.SeriesID, next.Recurrence.SeriesID)
+	assert.False(t, next.Checklist[0].Done)
+	repo.AssertExpectations(t)
+}
+
+func TestMaterializeOccurrencesFillsHorizon(t *testing.T) {
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	start := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	tails := map[primitive.ObjectID]Domain.Task{}
+	// a monthly series sorts first and its next occurrence is past the
+	// horizon; it must not keep the daily one from being continued
+	monthly := Domain.Task{
+		ID: primitive.NewObjectID(), Title: "review", DueDate: &start,
+		Recurrence: &Domain.TaskRecurrence{Rule: "FREQ=MONTHLY", Start: start, SeriesID: primitive.NewObjectID(), Index: 1},
+	}
+	first := Domain.Task{
+		ID: primitive.NewObjectID(), Title: "standup", DueDate: &start,
+		Recurrence: &Domain.TaskRecurrence{Rule: "FREQ=DAILY", Start: start, SeriesID: primitive.NewObjectID(), Index: 1},
+	}
+	tails[monthly.ID] = monthly
+	tails[first.ID] = first
+
+	// a tiny in-memory store: created occurrences become tails until marked.
+	// Pages hold a single tail.
+	repo.On("FindRecurring", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, before time.Time, after primitive.ObjectID, _ int) []Domain.Task {
+		var next *Domain.Task
+		for _, task := range tails {
+			if task.DueDate.Before(before) && task.ID.Hex() > after.Hex() && (next == nil || task.ID.Hex() < next.ID.Hex()) {
+				task := task
+				next = &task
+			}
+		}
+		if next == nil {
+			return []Domain.Task{}
+		}
+		return []Domain.Task{*next}
+	}, nil)
+	repo.On("Create", mock.Anything, mock.Anything).Return(func(_ context.Context, task Domain.Task) Domain.Task {
+		task.ID = primitive.NewObjectID()
+		tails[task.ID] = task
+		return task
+	}, nil)
+	repo.On("MarkNextCreated", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		delete(tails, args.Get(1).(primitive.ObjectID))
+	}).Return(nil)
+
+	n, err := uc.MaterializeOccurrences(context.Background(), 72*time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Len(t, tails, 2)
+	delete(tails, monthly.ID)
+	for _, last := range tails {
+		assert.Equal(t, 4, last.Recurrence.Index)
+		assert.Equal(t, start.AddDate(0, 0, 3), *last.DueDate)
+	}
+}
+
+func TestCreateTaskRejectsUnknownTag(t *testing.T) {
+	repo := newTaskRepo()
+	tags := new(mocks.MockTagRepository)
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), tags, new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	actor := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	tags.On("FindByNames", mock.Anything, []string{"backend", "urgent"}).Return([]Domain.Tag{{ID: primitive.NewObjectID(), Name: "backend"}}, nil)
+
+	_, err := uc.Create(context.Background(), actor, Domain.TaskInput{Title: "t", Tags: []string{" Backend", "urgent", "backend"}})
+	assert.ErrorIs(t, err, Domain.ErrUnknownTag)
+	assert.Contains(t, err.Error(), `"urgent"`)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestRenameTagUpdatesTasks(t *testing.T) {
+	repo := newTaskRepo()
+	tags := new(mocks.MockTagRepository)
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), tags, new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	admin := Domain.Actor{UserID: primitive.NewObjectID(), Username: "root", Role: "admin"}
+	tag := Domain.Tag{ID: primitive.NewObjectID(), Name: "bug", Color: "#ff0000"}
+	tags.On("FindByID", mock.Anything, tag.ID.Hex()).Return(tag, nil)
+	tags.On("FindByNames", mock.Anything, []string{"defect"}).Return([]Domain.Tag{}, nil)
+	tags.On("Update", mock.Anything, Domain.Tag{ID: tag.ID, Name: "defect", Color: "#00ff00"}).Return(func(_ context.Context, t Domain.Tag) Domain.Tag { return t }, nil)
+	task := Domain.Task{ID: primitive.NewObjectID(), Title: "t", Tags: []string{"bug", "defect", "ui"}, Version: 4}
+	repo.On("FindByTag", mock.Anything, "bug", mock.Anything).Return([]Domain.Task{task}, nil).Once()
+	repo.On("FindByTag", mock.Anything, "bug", mock.Anything).Return([]Domain.Task{}, nil)
+	repo.On("Update", mock.Anything, task.ID.Hex(), mock.MatchedBy(func(c Domain.TaskChanges) bool {
+		return assert.ObjectsAreEqual([]string{"defect", "ui"}, c.Set["tags"]) && *c.IfVersion == 4
+	})).Return(Domain.Task{ID: task.ID, Tags: []string{"defect", "ui"}, Version: 5}, nil)
+
+	_, err := uc.UpdateTag(context.Background(), admin, tag.ID.Hex(), Domain.TagInput{Name: "Defect", Color: "#00FF00"})
+	assert.NoError(t, err)
+
+	_, err = uc.UpdateTag(context.Background(), admin, tag.ID.Hex(), Domain.TagInput{Name: "defect", Color: "red"})
+	assert.ErrorIs(t, err, Domain.ErrInvalidTag)
+	repo.AssertNumberOfCalls(t, "Update", 1)
+	tags.AssertNumberOfCalls(t, "Update", 1)
+}
+
+func TestDeleteTagLeavesCatalogueUntilTasksAreUntagged(t *testing.T) {
+	repo := newTaskRepo()
+	tags := new(mocks.MockTagRepository)
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), tags, new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	admin := Domain.Actor{UserID: primitive.NewObjectID(), Username: "root", Role: "admin"}
+	tag := Domain.Tag{ID: primitive.NewObjectID(), Name: "bug"}
+	tags.On("FindByID", mock.Anything, tag.ID.Hex()).Return(tag, nil)
+	repo.On("FindByTag", mock.Anything, "bug", mock.Anything).Return([]Domain.Task{}, errors.New("db down")).Once()
+
+	// the catalogue entry stays so that a retry can finish the job
+	_, err := uc.DeleteTag(context.Background(), admin, tag.ID.Hex())
+	assert.Error(t, err)
+	tags.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+
+	task := Domain.Task{ID: primitive.NewObjectID(), Title: "t", Tags: []string{"bug"}, Version: 2}
+	repo.On("FindByTag", mock.Anything, "bug", mock.Anything).Return([]Domain.Task{task}, nil).Once()
+	repo.On("FindByTag", mock.Anything, "bug", mock.Anything).Return([]Domain.Task{}, nil)
+	repo.On("Update", mock.Anything, task.ID.Hex(), mock.MatchedBy(func(c Domain.TaskChanges) bool {
+		return assert.ObjectsAreEqual([]string{"tags"}, c.Unset)
+	})).Return(Domain.Task{ID: task.ID, Version: 3}, nil)
+	tags.On("Delete", mock.Anything, tag.ID).Return(true, nil)
+
+	ok, err := uc.DeleteTag(context.Background(), admin, tag.ID.Hex())
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestReprioritizeReportsEachTask(t *testing.T) {
+	repo := newTaskRepo()
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), anyProjects())
+
+	admin := Domain.Actor{UserID: primitive.NewObjectID(), Username: "root", Role: "admin"}
+	low := Domain.Task{ID: primitive.NewObjectID(), Title: "low", Priority: Domain.PriorityLow, PriorityRank: 1, Version: 2}
+	urgent := Domain.Task{ID: primitive.NewObjectID(), Title: "urgent", Priority: Domain.PriorityUrgent, PriorityRank: 4, Version: 5}
+	stale := Domain.Task{ID: primitive.NewObjectID(), Title: "stale", Priority: Domain.PriorityLow, PriorityRank: 1, Version: 7}
+	missing := primitive.NewObjectID()
+	repo.On("FindByID", mock.Anything, low.ID.Hex()).Return(low, nil)
+	repo.On("FindByID", mock.Anything, urgent.ID.Hex()).Return(urgent, nil)
+	repo.On("FindByID", mock.Anything, stale.ID.Hex()).Return(stale, nil)
+	repo.On("FindByID", mock.Anything, missing.Hex()).Return(Domain.Task{}, nil)
+	// changed since it was read; the other tasks still go through
+	repo.On("Update", mock.Anything, stale.ID.Hex(), mock.Anything).Return(Domain.Task{}, Domain.ErrVersionMismatch)
+	repo.On("Update", mock.Anything, low.ID.Hex(), mock.MatchedBy(func(c Domain.TaskChanges) bool {
+		return c.Set["priority"] == Domain.PriorityUrgent && c.Set["priority_rank"] == 4 && *c.IfVersion == 2
+	})).Return(func(_ context.Context, _ string, c Domain.TaskChanges) Domain.Task {
+		updated := low
+		updated.Priority, updated.PriorityRank, updated.Version = Domain.PriorityUrgent, 4, 3
+		return updated
+	}, nil)
+	repo.On("FindBlockedBy", mock.Anything, mock.Anything).Return([]Domain.Task{}, nil).Maybe()
+
+	// a malformed id is reported like a missing one
+	update := Domain.PriorityUpdate{TaskIDs: []string{stale.ID.Hex(), low.ID.Hex(), urgent.ID.Hex(), missing.Hex(), "not-hex"}, Priority: Domain.PriorityUrgent}
+	result, err := uc.Reprioritize(context.Background(), admin, update)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{low.ID.Hex()}, result.Updated)
+	assert.Equal(t, []string{urgent.ID.Hex()}, result.Unchanged)
+	assert.Equal(t, []string{missing.Hex(), "not-hex"}, result.NotFound)
+	assert.Equal(t, []string{stale.ID.Hex()}, result.Failed)
+
+	user := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	_, err = uc.Reprioritize(context.Background(), user, update)
+	assert.ErrorIs(t, err, Domain.ErrForbidden)
+
+	update.Priority = "critical"
+	_, err = uc.Reprioritize(context.Background(), admin, update)
+	assert.ErrorIs(t, err, Domain.ErrInvalidPriority)
+	repo.AssertNumberOfCalls(t, "Update", 2)
+}
+
+func TestAddCommentValidatesMentions(t *testing.T) {
+	repo := newTaskRepo()
+	users := new(mocks.MockUserRepository)
+	comments := new(mocks.MockCommentRepository)
+	uc := Usecases.NewTaskUsecase(repo, users, anyHistory(), new(mocks.MockTagRepository), comments, new(mocks.MockBlobStore), anyProjects())
+
+	author := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	abebe := Domain.User{ID: primitive.NewObjectID(), Username: "abebe", Role: "user"}
+	task := Domain.Task{ID: primitive.NewObjectID(), OwnerID: author.UserID, Title: "t", SharedWith: []primitive.ObjectID{abebe.ID}}
+	repo.On("FindByID", mock.Anything, task.ID.Hex()).Return(task, nil)
+	users.On("FindByUsername", mock.Anything, "abebe").Return(abebe, nil)
+	users.On("FindByUsername", mock.Anything, "ghost").Return(Domain.User{}, nil)
+	users.On("FindByUsername", mock.Anything, "outsider").Return(Domain.User{ID: primitive.NewObjectID(), Username: "outsider", Role: "user"}, nil)
+	comments.On("Create", mock.Anything, mock.Anything).Return(func(_ context.Context, c Domain.Comment) Domain.Comment {
+		c.ID = primitive.NewObjectID()
+		return c
+	}, nil)
+
+	_, err := uc.AddComment(context.Background(), author, task.ID.Hex(), Domain.CommentInput{Body: "ping @abebe and @ghost"})
+	assert.ErrorIs(t, err, Domain.ErrUnknownMention)
+
+	// users who cannot see the task cannot be mentioned on it
+	_, err = uc.AddComment(context.Background(), author, task.ID.Hex(), Domain.CommentInput{Body: "ping @outsider"})
+	assert.ErrorIs(t, err, Domain.ErrUnknownMention)
+
+	_, err = uc.AddComment(context.Background(), author, task.ID.Hex(), Domain.CommentInput{Body: "   "})
+	assert.ErrorIs(t, err, Domain.ErrInvalidComment)
+
+	c, err := uc.AddComment(context.Background(), author, task.ID.Hex(), Domain.CommentInput{Body: "@abebe can you review? mail me at kidus@example.com, thanks @abebe."})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"abebe"}, c.Mentions)
+	assert.Equal(t, "kidus", c.Author)
+	assert.Equal(t, task.ID, c.TaskID)
+	comments.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestOnlyAuthorCanEditComment(t *testing.T) {
+	repo := newTaskRepo()
+	comments := new(mocks.MockCommentRepository)
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), comments, new(mocks.MockBlobStore), anyProjects())
+
+	author := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	admin := Domain.Actor{UserID: primitive.NewObjectID(), Username: "root", Role: "admin"}
+	task := Domain.Task{ID: primitive.NewObjectID(), OwnerID: author.UserID, Title: "t"}
+	c := Domain.Comment{ID: primitive.NewObjectID(), TaskID: task.ID, AuthorID: author.UserID, Author: "kidus", Body: "first"}
+	repo.On("FindByID", mock.Anything, task.ID.Hex()).Return(task, nil)
+	comments.On("FindByID", mock.Anything, c.ID.Hex()).Return(c, nil)
+	comments.On("UpdateBody", mock.Anything, c.ID, "second", []string(nil)).Return(c, nil)
+	comments.On("Delete", mock.Anything, c.ID).Return(true, nil)
+
+	_, err := uc.EditComment(context.Background(), admin, task.ID.Hex(), c.ID.Hex(), Domain.CommentInput{Body: "second"})
+	assert.ErrorIs(t, err, Domain.ErrNotCommentAuthor)
+
+	_, err = uc.EditComment(context.Background(), author, task.ID.Hex(), c.ID.Hex(), Domain.CommentInput{Body: "second"})
+	assert.NoError(t, err)
+
+	// a comment is only reachable through its own task
+	other := Domain.Task{ID: primitive.NewObjectID(), OwnerID: author.UserID, Title: "other"}
+	repo.On("FindByID", mock.Anything, other.ID.Hex()).Return(other, nil)
+	ok, err := uc.DeleteComment(context.Background(), admin, other.ID.Hex(), c.ID.Hex())
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = uc.DeleteComment(context.Background(), admin, task.ID.Hex(), c.ID.Hex())
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestAddAttachmentStoresFileAndChecksum(t *testing.T) {
+	repo := newTaskRepo()
+	blobs, err := storage.NewLocalBlobStore(t.TempDir())
+	assert.NoError(t, err)
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), blobs, anyProjects())
+
+	owner := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	task := Domain.Task{ID: primitive.NewObjectID(), Title: "t", OwnerID: owner.UserID, Version: 1}
+	repo.On("FindByID", mock.Anything, task.ID.Hex()).Return(task, nil)
+	repo.On("Update", mock.Anything, task.ID.Hex(), mock.Anything).Return(func(_ context.Context, _ string, c Domain.TaskChanges) Domain.Task {
+		updated := task
+		updated.Attachments = append(updated.Attachments, c.AddToSet["attachments"].(Domain.Attachment))
+		updated.Version++
+		return updated
+	}, nil)
+
+	att, err := uc.AddAttachment(context.Background(), owner, task.ID.Hex(), `C:\docs\notes.txt`, "", strings.NewReader("meeting notes"), nil)
+	assert.NoError(t, err)
+	sum := sha256.Sum256([]byte("meeting notes"))
+	assert.Equal(t, "notes.txt", att.Name)
+	assert.Equal(t, "text/plain", att.ContentType)
+	assert.Equal(t, int64(13), att.Size)
+	assert.Equal(t, hex.EncodeToString(sum[:]), att.SHA256)
+
+	r, err := blobs.Open(context.Background(), att.ID.Hex())
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "meeting notes", string(body))
+}
+
+func TestAddAttachmentEnforcesPolicy(t *testing.T) {
+	repo := newTaskRepo()
+	blobs := new(mocks.MockBlobStore)
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), blobs, anyProjects())
+	uc.SetAttachmentPolicy(Domain.AttachmentPolicy{MaxSize: 4, MaxPerTask: 5, AllowedTypes: []string{"text/*"}})
+
+	owner := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	task := Domain.Task{ID: primitive.NewObjectID(), Title: "t", OwnerID: owner.UserID, Version: 1}
+	repo.On("FindByID", mock.Anything, task.ID.Hex()).Return(task, nil)
+	blobs.On("Put", mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, _ string, r io.Reader) int64 {
+		n, _ := io.Copy(io.Discard, r)
+		return n
+	}, nil)
+	blobs.On("Delete", mock.Anything, mock.Anything).Return(nil)
+
+	_, err := uc.AddAttachment(context.Background(), owner, task.ID.Hex(), "big.txt", "text/plain", strings.NewReader("too long"), nil)
+	assert.ErrorIs(t, err, Domain.ErrAttachmentTooLarge)
+	blobs.AssertNumberOfCalls(t, "Delete", 1)
+
+	_, err = uc.AddAttachment(context.Background(), owner, task.ID.Hex(), "a.pdf", "", strings.NewReader("%PDF-1.4"), nil)
+	assert.ErrorIs(t, err, Domain.ErrUnsupportedMediaType)
+
+	// the sniffed type wins over the declared one
+	_, err = uc.AddAttachment(context.Background(), owner, task.ID.Hex(), "a.txt", "text/plain", strings.NewReader("%PDF-1.4"), nil)
+	assert.ErrorIs(t, err, Domain.ErrUnsupportedMediaType)
+	blobs.AssertNumberOfCalls(t, "Put", 1)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateTaskInProjectNeedsEditorRole(t *testing.T) {
+	repo := newTaskRepo()
+	projects := new(mocks.MockProjectRepository)
+	uc := Usecases.NewTaskUsecase(repo, new(mocks.MockUserRepository), anyHistory(), new(mocks.MockTagRepository), new(mocks.MockCommentRepository), new(mocks.MockBlobStore), projects)
+
+	editor := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	viewer := Domain.Actor{UserID: primitive.NewObjectID(), Username: "abebe", Role: "user"}
+	outsider := Domain.Actor{UserID: primitive.NewObjectID(), Username: "sara", Role: "user"}
+	p := Domain.Project{ID: primitive.NewObjectID(), Members: []Domain.ProjectMember{
+		{UserID: editor.UserID, Role: Domain.ProjectEditor},
+		{UserID: viewer.UserID, Role: Domain.ProjectViewer},
+	}}
+	projects.On("FindByID", mock.Anything, p.ID.Hex()).Return(p, nil)
+	repo.On("Create", mock.Anything, mock.MatchedBy(func(task Domain.Task) bool { return task.ProjectID == p.ID })).
+		Return(func(_ context.Context, task Domain.Task) Domain.Task { return task }, nil)
+
+	_, err := uc.Create(context.Background(), outsider, Domain.TaskInput{Title: "t", ProjectID: p.ID})
+	assert.ErrorIs(t, err, Domain.ErrProjectNotFound)
+	_, err = uc.Create(context.Background(), viewer, Domain.TaskInput{Title: "t", ProjectID: p.ID})
+	assert.ErrorIs(t, err, Domain.ErrForbidden)
+	created, err := uc.Create(context.Background(), editor, Domain.TaskInput{Title: "t", ProjectID: p.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, p.ID, created.ProjectID)
+	repo.AssertNumberOfCalls(t, "Create", 1)
+}
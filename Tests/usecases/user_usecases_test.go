@@ -2,28 +2,146 @@ package usecases_test
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"task_manager1/Domain"
 	"task_manager1/Infrastructure/security"
 	"task_manager1/Tests/mocks"
 	"task_manager1/Usecases"
 )
 
-func TestRegisterFirstUserBecomesAdmin(t *testing.T) {
+func TestRegisterCreatesOrganizationWithItsAdmin(t *testing.T) {
 	repo := new(mocks.MockUserRepository)
+	orgs := new(mocks.MockOrganizationRepository)
 	pw := security.NewPasswordService()
-	uc := Usecases.NewUserUsecase(repo, pw)
+	uc := Usecases.NewUserUsecase(repo, orgs, new(mocks.MockRefreshTokenRepository), pw)
+	org := primitive.NewObjectID()
 
-	repo.On("Count", mock.Anything).Return(int64(0), nil)
-	repo.On("Create", mock.Anything, mock.Anything).
+	repo.On("FindByUsername", mock.Anything, "kidus").Return(Domain.User{}, nil)
+	orgs.On("Create", mock.Anything, mock.MatchedBy(func(o Domain.Organization) bool {
+		return o.Name == "Acme" && o.CreatedBy == "kidus"
+	})).Return(Domain.Organization{ID: org, Name: "Acme"}, nil)
+	repo.On("Create", mock.MatchedBy(func(ctx context.Context) bool {
+		id, all, ok := Domain.TenantFromContext(ctx)
+		return ok && !all && id == org
+	}), mock.Anything).
 		Return(func(_ context.Context, u Domain.User) Domain.User {
+			u.TenantID = org
 			return u
 		}, nil)
 
-	user, err := uc.Register(context.Background(), "kidus", "123")
+	user, err := uc.Register(context.Background(), "kidus", "123", "  Acme ")
 	assert.NoError(t, err)
 	assert.Equal(t, "admin", user.Role)
+	assert.Equal(t, org, user.TenantID)
+
+	// the username lookup must span every organisation
+	_, all, _ := Domain.TenantFromContext(repo.Calls[0].Arguments.Get(0).(context.Context))
+	assert.True(t, all)
+}
+
+func TestRegisterRemovesOrganizationWhenUserFails(t *testing.T) {
+	repo := new(mocks.MockUserRepository)
+	orgs := new(mocks.MockOrganizationRepository)
+	uc := Usecases.NewUserUsecase(repo, orgs, new(mocks.MockRefreshTokenRepository), security.NewPasswordService())
+	org := primitive.NewObjectID()
+
+	repo.On("FindByUsername", mock.Anything, "kidus").Return(Domain.User{}, nil)
+	orgs.On("Create", mock.Anything, mock.Anything).Return(Domain.Organization{ID: org, Name: "Acme"}, nil)
+	repo.On("Create", mock.Anything, mock.Anything).Return(Domain.User{}, errors.New("username already exists"))
+	orgs.On("Delete", mock.Anything, org).Return(true, nil)
+
+	_, err := uc.Register(context.Background(), "kidus", "123", "Acme")
+	assert.Error(t, err)
+	orgs.AssertCalled(t, "Delete", mock.Anything, org)
+
+	_, err = uc.Register(context.Background(), "kidus", "123", " ")
+	assert.ErrorIs(t, err, Domain.ErrInvalidOrganization)
+}
+
+func TestRefreshRotatesTokenAndRevokesFamilyOnReuse(t *testing.T) {
+	repo := new(mocks.MockUserRepository)
+	tokens := new(mocks.MockRefreshTokenRepository)
+	uc := Usecases.NewUserUsecase(repo, new(mocks.MockOrganizationRepository), tokens, security.NewPasswordService())
+	user := Domain.User{ID: primitive.NewObjectID(), TenantID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+
+	var issued []Domain.RefreshToken
+	tokens.On("Create", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, rt Domain.RefreshToken) Domain.RefreshToken {
+			rt.ID = primitive.NewObjectID()
+			issued = append(issued, rt)
+			return rt
+		}, nil)
+
+	first, err := uc.StartSession(context.Background(), user)
+	assert.NoError(t, err)
+	assert.Len(t, issued, 1)
+	assert.NotEqual(t, first, issued[0].Hash, "only a hash is stored")
+
+	stored := issued[0]
+	tokens.On("FindByHash", mock.Anything, stored.Hash).Return(stored, nil).Once()
+	tokens.On("MarkUsed", mock.Anything, stored.ID, mock.Anything).Return(true, nil).Once()
+	repo.On("FindByUsername", mock.Anything, "kidus").Return(user, nil)
+
+	got, second, err := uc.Refresh(context.Background(), first)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+	assert.NotEqual(t, first, second)
+	assert.Len(t, issued, 2)
+	assert.Equal(t, stored.FamilyID, issued[1].FamilyID)
+
+	// presenting the rotated token again revokes the family
+	used := stored
+	now := time.Now()
+	used.UsedAt = &now
+	tokens.On("FindByHash", mock.Anything, stored.Hash).Return(used, nil).Once()
+	tokens.On("RevokeFamily", mock.Anything, stored.FamilyID, mock.Anything).Return(int64(1), nil).Once()
+
+	_, _, err = uc.Refresh(context.Background(), first)
+	assert.ErrorIs(t, err, Domain.ErrRefreshTokenReused)
+	tokens.AssertExpectations(t)
+}
+
+func TestRefreshKeepsTokenWhenSuccessorCannotBeIssued(t *testing.T) {
+	repo := new(mocks.MockUserRepository)
+	tokens := new(mocks.MockRefreshTokenRepository)
+	uc := Usecases.NewUserUsecase(repo, new(mocks.MockOrganizationRepository), tokens, security.NewPasswordService())
+	user := Domain.User{ID: primitive.NewObjectID(), TenantID: primitive.NewObjectID(), Username: "kidus", Role: "user"}
+	stored := Domain.RefreshToken{
+		ID: primitive.NewObjectID(), TenantID: user.TenantID, UserID: user.ID, Username: "kidus",
+		FamilyID: primitive.NewObjectID(), ExpiresAt: time.Now().Add(time.Hour),
+	}
+	tokens.On("FindByHash", mock.Anything, security.HashToken("token")).Return(stored, nil)
+	repo.On("FindByUsername", mock.Anything, "kidus").Return(user, nil)
+	tokens.On("Create", mock.Anything, mock.Anything).Return(Domain.RefreshToken{}, errors.New("db down"))
+
+	// the presented token stays unused so the client can try again
+	_, _, err := uc.Refresh(context.Background(), "token")
+	assert.Error(t, err)
+	tokens.AssertNotCalled(t, "MarkUsed", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDisableUserEndsSessionsButNotOwnAccount(t *testing.T) {
+	repo := new(mocks.MockUserRepository)
+	tokens := new(mocks.MockRefreshTokenRepository)
+	uc := Usecases.NewUserUsecase(repo, new(mocks.MockOrganizationRepository), tokens, security.NewPasswordService())
+	admin := Domain.Actor{UserID: primitive.NewObjectID(), Username: "kidus", Role: "admin"}
+	target := Domain.User{ID: primitive.NewObjectID(), Username: "abebe", Role: "user", Disabled: true, TokenVersion: 1}
+
+	_, err := uc.SetDisabled(context.Background(), admin, "kidus", true)
+	assert.ErrorIs(t, err, Domain.ErrOwnAccount)
+	_, err = uc.Demote(context.Background(), admin, "kidus")
+	assert.ErrorIs(t, err, Domain.ErrOwnAccount)
+
+	repo.On("SetDisabled", mock.Anything, "abebe", true).Return(target, nil)
+	tokens.On("RevokeUser", mock.Anything, target.ID, mock.Anything).Return(int64(2), nil)
+	updated, err := uc.SetDisabled(context.Background(), admin, "abebe", true)
+	assert.NoError(t, err)
+	assert.True(t, updated.Disabled)
+	tokens.AssertExpectations(t)
 }
@@ -0,0 +1,61 @@
+package Usecases
+
+import (
+	"fmt"
+	"time"
+
+	"task_manager1/Domain"
+)
+
+const dateOnlyLayout = "2006-01-02"
+
+// ParseDueDate accepts an RFC 3339 timestamp or a YYYY-MM-DD date. Date-only
+// values are interpreted in loc, at the start of the day or, when endOfDay is
+// set, at its last second so that a task due "on" a date is not overdue until
+// that day has passed.
+func ParseDueDate(value string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	ts, ok := parseDate(value, loc, endOfDay)
+	if !ok {
+		return time.Time{}, Domain.ErrInvalidDueDate
+	}
+	return ts, nil
+}
+
+// ParseDateBound parses a list filter bound such as created_after with the
+// same rules as ParseDueDate.
+func ParseDateBound(name, value string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	ts, ok := parseDate(value, loc, endOfDay)
+	if !ok {
+		return time.Time{}, fmt.Errorf("%w: %s must be RFC 3339 or YYYY-MM-DD", Domain.ErrInvalidQuery, name)
+	}
+	return ts, nil
+}
+
+func parseDate(value string, loc *time.Location, endOfDay bool) (time.Time, bool) {
+	if ts, err := time.Parse(time.RFC3339, value); err == nil {
+		return ts.UTC(), true
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	d, err := time.ParseInLocation(dateOnlyLayout, value, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if endOfDay {
+		d = time.Date(d.Year(), d.Month(), d.Day(), 23, 59, 59, 0, loc)
+	}
+	return d.UTC(), true
+}
+
+// LoadTimezone resolves an IANA zone name, treating "" as UTC.
+func LoadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, Domain.ErrInvalidTimezone
+	}
+	return loc, nil
+}
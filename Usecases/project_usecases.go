@@ -0,0 +1,163 @@
+package Usecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"task_manager1/Domain"
+	"task_manager1/Repositories"
+)
+
+// maxProjectDescription bounds the length of a project description in bytes.
+const maxProjectDescription = 2000
+
+// ProjectUsecase holds dependencies for project business rules.
+type ProjectUsecase struct {
+	repo  Repositories.ProjectRepository
+	tasks Repositories.TaskRepository
+	users Repositories.UserRepository
+}
+
+func NewProjectUsecase(r Repositories.ProjectRepository, tasks Repositories.TaskRepository, users Repositories.UserRepository) *ProjectUsecase {
+	return &ProjectUsecase{repo: r, tasks: tasks, users: users}
+}
+
+// List returns the projects the actor is a member of, or every project for admins.
+func (p *ProjectUsecase) List(ctx context.Context, actor Domain.Actor) ([]Domain.Project, error) {
+	if actor.IsAdmin() {
+		return p.repo.List(ctx)
+	}
+	return p.repo.ListByMember(ctx, actor.UserID)
+}
+
+// GetByID returns a project the actor can see, or an empty project when it
+// does not exist or is hidden.
+func (p *ProjectUsecase) GetByID(ctx context.Context, actor Domain.Actor, id string) (Domain.Project, error) {
+	project, err := p.repo.FindByID(ctx, id)
+	if err != nil || project.ID.IsZero() || !project.CanView(actor) {
+		return Domain.Project{}, err
+	}
+	return project, nil
+}
+
+// manageable loads a project the actor owns. It returns an empty project
+// when the project does not exist or is hidden from the actor.
+func (p *ProjectUsecase) manageable(ctx context.Context, actor Domain.Actor, id string) (Domain.Project, error) {
+	project, err := p.GetByID(ctx, actor, id)
+	if err != nil || project.ID.IsZero() {
+		return Domain.Project{}, err
+	}
+	if !project.CanManage(actor) {
+		return Domain.Project{}, Domain.ErrNotProjectOwner
+	}
+	return project, nil
+}
+
+// projectFields validates the client editable fields of a project.
+func projectFields(input Domain.ProjectInput) (Domain.Project, error) {
+	name, err := Domain.NormalizeProjectName(input.Name)
+	if err != nil {
+		return Domain.Project{}, err
+	}
+	desc := strings.TrimSpace(input.Description)
+	if len(desc) > maxProjectDescription {
+		return Domain.Project{}, fmt.Errorf("%w: description is longer than %d bytes", Domain.ErrInvalidProject, maxProjectDescription)
+	}
+	return Domain.Project{Name: name, Description: desc}, nil
+}
+
+// Create creates a project with the actor as its owner.
+func (p *ProjectUsecase) Create(ctx context.Context, actor Domain.Actor, input Domain.ProjectInput) (Domain.Project, error) {
+	if actor.UserID.IsZero() {
+		return Domain.Project{}, Domain.ErrForbidden
+	}
+	project, err := projectFields(input)
+	if err != nil {
+		return Domain.Project{}, err
+	}
+	project.Members = []Domain.ProjectMember{{UserID: actor.UserID, Username: actor.Username, Role: Domain.ProjectOwner}}
+	project.CreatedBy = actor.Username
+	return p.repo.Create(ctx, project)
+}
+
+// Update replaces the name and description of a project (owners).
+func (p *ProjectUsecase) Update(ctx context.Context, actor Domain.Actor, id string, input Domain.ProjectInput) (Domain.Project, error) {
+	fields, err := projectFields(input)
+	if err != nil {
+		return Domain.Project{}, err
+	}
+	existing, err := p.manageable(ctx, actor, id)
+	if err != nil || existing.ID.IsZero() {
+		return Domain.Project{}, err
+	}
+	fields.ID = existing.ID
+	return p.repo.Update(ctx, fields)
+}
+
+// Delete removes a project (owners). Its tasks, including those in the
+// trash, have to be moved or purged first.
+func (p *ProjectUsecase) Delete(ctx context.Context, actor Domain.Actor, id string) (bool, error) {
+	existing, err := p.manageable(ctx, actor, id)
+	if err != nil || existing.ID.IsZero() {
+		return false, err
+	}
+	n, err := p.tasks.CountByProject(ctx, existing.ID)
+	if err != nil {
+		return false, err
+	}
+	if n > 0 {
+		return false, fmt.Errorf("%w: %d tasks left", Domain.ErrProjectNotEmpty, n)
+	}
+	return p.repo.Delete(ctx, existing.ID)
+}
+
+// SetMember adds a user to a project or changes their role (owners). The
+// last owner cannot be demoted; the repository checks this again as part of
+// the write, so concurrent demotions cannot both get through.
+func (p *ProjectUsecase) SetMember(ctx context.Context, actor Domain.Actor, id, username, role string) (Domain.Project, error) {
+	if !Domain.IsProjectRole(role) {
+		return Domain.Project{}, fmt.Errorf("%w: role must be %q, %q or %q", Domain.ErrInvalidProject, Domain.ProjectOwner, Domain.ProjectEditor, Domain.ProjectViewer)
+	}
+	existing, err := p.manageable(ctx, actor, id)
+	if err != nil || existing.ID.IsZero() {
+		return Domain.Project{}, err
+	}
+	u, err := p.users.FindByUsername(ctx, username)
+	if err != nil {
+		return Domain.Project{}, err
+	}
+	if u.Username == "" {
+		return Domain.Project{}, Domain.ErrUserNotFound
+	}
+	if role != Domain.ProjectOwner && existing.RoleOf(u.ID) == Domain.ProjectOwner && existing.Owners() == 1 {
+		return Domain.Project{}, Domain.ErrLastProjectOwner
+	}
+	return p.repo.SetMember(ctx, existing.ID, Domain.ProjectMember{UserID: u.ID, Username: u.Username, Role: role})
+}
+
+// RemoveMember takes a user out of a project. Owners can remove anyone and
+// every member can leave; the last owner cannot, which the repository checks
+// again as part of the write.
+func (p *ProjectUsecase) RemoveMember(ctx context.Context, actor Domain.Actor, id, username string) (Domain.Project, error) {
+	existing, err := p.GetByID(ctx, actor, id)
+	if err != nil || existing.ID.IsZero() {
+		return Domain.Project{}, err
+	}
+	if username != actor.Username && !existing.CanManage(actor) {
+		return Domain.Project{}, Domain.ErrNotProjectOwner
+	}
+	var member Domain.ProjectMember
+	for _, m := range existing.Members {
+		if m.Username == username {
+			member = m
+		}
+	}
+	if member.UserID.IsZero() {
+		return Domain.Project{}, Domain.ErrUserNotFound
+	}
+	if member.Role == Domain.ProjectOwner && existing.Owners() == 1 {
+		return Domain.Project{}, Domain.ErrLastProjectOwner
+	}
+	return p.repo.RemoveMember(ctx, existing.ID, member.UserID)
+}
@@ -0,0 +1,187 @@
+package Usecases
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"task_manager1/Domain"
+	"task_manager1/Infrastructure/storage"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxAttachmentName bounds the length of an attachment's file name.
+const maxAttachmentName = 255
+
+// SetAttachmentPolicy replaces the default upload limits.
+func (t *TaskUsecase) SetAttachmentPolicy(p Domain.AttachmentPolicy) {
+	t.attachments = p
+}
+
+// AttachmentPolicy returns the limits enforced on uploads.
+func (t *TaskUsecase) AttachmentPolicy() Domain.AttachmentPolicy {
+	return t.attachments
+}
+
+// AddAttachment streams body into the blob store and attaches it to the
+// task. The media type is sniffed from the first bytes; contentType only
+// names binary files the sniffer does not recognise. It returns an empty
+// attachment when the task does not exist or is hidden from the actor.
+func (t *TaskUsecase) AddAttachment(ctx context.Context, actor Domain.Actor, taskID, name, contentType string, body io.Reader, ifVersion *int64) (Domain.Attachment, error) {
+	name, err := attachmentName(name)
+	if err != nil {
+		return Domain.Attachment{}, err
+	}
+	existing, err := t.editable(ctx, actor, taskID, ifVersion)
+	if err != nil || existing.ID.IsZero() {
+		return Domain.Attachment{}, err
+	}
+	if len(existing.Attachments) >= t.attachments.MaxPerTask {
+		return Domain.Attachment{}, fmt.Errorf("%w: a task can have at most %d attachments", Domain.ErrInvalidAttachment, t.attachments.MaxPerTask)
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(body, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return Domain.Attachment{}, err
+	}
+	if n == 0 {
+		return Domain.Attachment{}, fmt.Errorf("%w: file is empty", Domain.ErrInvalidAttachment)
+	}
+	head = head[:n]
+	mediaType := attachmentType(contentType, head)
+	if !t.attachments.Allows(mediaType) {
+		return Domain.Attachment{}, fmt.Errorf("%w: %s", Domain.ErrUnsupportedMediaType, mediaType)
+	}
+
+	att := Domain.Attachment{
+		ID:          primitive.NewObjectID(),
+		Name:        name,
+		ContentType: mediaType,
+		UploadedBy:  actor.Username,
+		UploadedAt:  time.Now().UTC().Truncate(time.Millisecond),
+	}
+	hash := sha256.New()
+	// read one byte past the limit to tell a file of exactly MaxSize from a larger one
+	limited := io.LimitReader(io.MultiReader(bytes.NewReader(head), body), t.attachments.MaxSize+1)
+	size, err := t.blobs.Put(ctx, att.ID.Hex(), io.TeeReader(limited, hash))
+	if err == nil && size > t.attachments.MaxSize {
+		err = fmt.Errorf("%w: the limit is %d bytes", Domain.ErrAttachmentTooLarge, t.attachments.MaxSize)
+	}
+	if err != nil {
+		t.deleteBlobs(ctx, att)
+		return Domain.Attachment{}, err
+	}
+	att.Size = size
+	att.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	updated, err := t.apply(ctx, actor, Domain.HistoryUpdated, existing, Domain.TaskChanges{
+		AddToSet:  map[string]interface{}{"attachments": att},
+		IfVersion: &existing.Version,
+		UpdatedBy: actor.Username,
+	})
+	if err != nil || updated.ID.IsZero() {
+		t.deleteBlobs(ctx, att)
+		return Domain.Attachment{}, raced(err, ifVersion)
+	}
+	return att, nil
+}
+
+// OpenAttachment returns an attachment of a task the actor can see together
+// with its contents, which the caller must close. It returns an empty
+// attachment when the task is not visible.
+func (t *TaskUsecase) OpenAttachment(ctx context.Context, actor Domain.Actor, taskID, attachmentID string) (Domain.Attachment, io.ReadCloser, error) {
+	task, err := t.visible(ctx, actor, taskID)
+	if err != nil || task.ID.IsZero() {
+		return Domain.Attachment{}, nil, err
+	}
+	att, ok := task.Attachment(attachmentID)
+	if !ok {
+		return Domain.Attachment{}, nil, Domain.ErrAttachmentNotFound
+	}
+	r, err := t.blobs.Open(ctx, att.ID.Hex())
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		return Domain.Attachment{}, nil, Domain.ErrAttachmentNotFound
+	}
+	if err != nil {
+		return Domain.Attachment{}, nil, err
+	}
+	return att, r, nil
+}
+
+// RemoveAttachment detaches a file from the task and deletes its contents.
+func (t *TaskUsecase) RemoveAttachment(ctx context.Context, actor Domain.Actor, taskID, attachmentID string, ifVersion *int64) (Domain.Task, error) {
+	existing, err := t.editable(ctx, actor, taskID, ifVersion)
+	if err != nil || existing.ID.IsZero() {
+		return Domain.Task{}, err
+	}
+	att, ok := existing.Attachment(attachmentID)
+	if !ok {
+		return Domain.Task{}, Domain.ErrAttachmentNotFound
+	}
+	updated, err := t.apply(ctx, actor, Domain.HistoryUpdated, existing, Domain.TaskChanges{
+		Pull:      map[string]interface{}{"attachments": map[string]interface{}{"id": att.ID}},
+		IfVersion: &existing.Version,
+		UpdatedBy: actor.Username,
+	})
+	if err == nil && !updated.ID.IsZero() {
+		t.deleteBlobs(ctx, att)
+	}
+	return updated, raced(err, ifVersion)
+}
+
+// deleteBlobs removes the contents of attachments. Failures only leave an
+// unreferenced blob behind, so they are logged rather than returned.
+func (t *TaskUsecase) deleteBlobs(ctx context.Context, attachments ...Domain.Attachment) {
+	for _, att := range attachments {
+		if err := t.blobs.Delete(ctx, att.ID.Hex()); err != nil {
+			log.Printf("attachments: deleting blob %s: %v", att.ID.Hex(), err)
+		}
+	}
+}
+
+// attachmentName reduces a client supplied file name to its last path
+// element without control characters.
+func attachmentName(name string) (string, error) {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name))
+	if name == "" || name == "." || name == "/" || name == ".." {
+		return "", fmt.Errorf("%w: file name required", Domain.ErrInvalidAttachment)
+	}
+	if utf8.RuneCountInString(name) > maxAttachmentName {
+		return "", fmt.Errorf("%w: file name is longer than %d characters", Domain.ErrInvalidAttachment, maxAttachmentName)
+	}
+	return name, nil
+}
+
+// attachmentType sniffs the media type from head, so a client cannot label
+// HTML as an image. The declared type is only used when sniffing finds
+// nothing more specific than the generic binary type.
+func attachmentType(declared string, head []byte) string {
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if sniffed != "application/octet-stream" {
+		return sniffed
+	}
+	if mediaType, _, err := mime.ParseMediaType(declared); err == nil {
+		return strings.ToLower(mediaType)
+	}
+	return sniffed
+}
@@ -0,0 +1,120 @@
+package Usecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"task_manager1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxChecklistItems bounds the size of a task's checklist.
+const maxChecklistItems = 100
+
+// AddChecklistItem appends an unchecked item to the task's checklist.
+func (t *TaskUsecase) AddChecklistItem(ctx context.Context, actor Domain.Actor, id, text string, ifVersion *int64) (Domain.Task, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return Domain.Task{}, fmt.Errorf("%w: item text required", Domain.ErrInvalidChecklist)
+	}
+	existing, err := t.editable(ctx, actor, id, ifVersion)
+	if err != nil || existing.ID.IsZero() {
+		return Domain.Task{}, err
+	}
+	if len(existing.Checklist) >= maxChecklistItems {
+		return Domain.Task{}, fmt.Errorf("%w: at most %d items", Domain.ErrInvalidChecklist, maxChecklistItems)
+	}
+	checklist := append(append([]Domain.ChecklistItem{}, existing.Checklist...), Domain.ChecklistItem{
+		ID:   primitive.NewObjectID(),
+		Text: text,
+	})
+	return t.setChecklist(ctx, actor, existing, checklist, ifVersion)
+}
+
+// UpdateChecklistItem changes the text and/or done flag of one item. Nil
+// arguments leave the value unchanged.
+func (t *TaskUsecase) UpdateChecklistItem(ctx context.Context, actor Domain.Actor, id, itemID string, text *string, done *bool, ifVersion *int64) (Domain.Task, error) {
+	existing, err := t.editable(ctx, actor, id, ifVersion)
+	if err != nil || existing.ID.IsZero() {
+		return Domain.Task{}, err
+	}
+	i, err := checklistIndex(existing.Checklist, itemID)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	checklist := append([]Domain.ChecklistItem{}, existing.Checklist...)
+	if text != nil {
+		if checklist[i].Text = strings.TrimSpace(*text); checklist[i].Text == "" {
+			return Domain.Task{}, fmt.Errorf("%w: item text required", Domain.ErrInvalidChecklist)
+		}
+	}
+	if done != nil {
+		checklist[i].Done = *done
+	}
+	return t.setChecklist(ctx, actor, existing, checklist, ifVersion)
+}
+
+// ReorderChecklist puts the checklist in the order of itemIDs, which must
+// name every item exactly once.
+func (t *TaskUsecase) ReorderChecklist(ctx context.Context, actor Domain.Actor, id string, itemIDs []string, ifVersion *int64) (Domain.Task, error) {
+	existing, err := t.editable(ctx, actor, id, ifVersion)
+	if err != nil || existing.ID.IsZero() {
+		return Domain.Task{}, err
+	}
+	if len(itemIDs) != len(existing.Checklist) {
+		return Domain.Task{}, fmt.Errorf("%w: order must list every item exactly once", Domain.ErrInvalidChecklist)
+	}
+	checklist := make([]Domain.ChecklistItem, 0, len(itemIDs))
+	seen := map[int]bool{}
+	for _, itemID := range itemIDs {
+		i, err := checklistIndex(existing.Checklist, itemID)
+		if err != nil || seen[i] {
+			return Domain.Task{}, fmt.Errorf("%w: order must list every item exactly once", Domain.ErrInvalidChecklist)
+		}
+		seen[i] = true
+		checklist = append(checklist, existing.Checklist[i])
+	}
+	return t.setChecklist(ctx, actor, existing, checklist, ifVersion)
+}
+
+// RemoveChecklistItem deletes one item from the checklist.
+func (t *TaskUsecase) RemoveChecklistItem(ctx context.Context, actor Domain.Actor, id, itemID string, ifVersion *int64) (Domain.Task, error) {
+	existing, err := t.editable(ctx, actor, id, ifVersion)
+	if err != nil || existing.ID.IsZero() {
+		return Domain.Task{}, err
+	}
+	i, err := checklistIndex(existing.Checklist, itemID)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	checklist := append(append([]Domain.ChecklistItem{}, existing.Checklist[:i]...), existing.Checklist[i+1:]...)
+	return t.setChecklist(ctx, actor, existing, checklist, ifVersion)
+}
+
+// setChecklist writes the whole checklist against the version it was read
+// from, so concurrent edits cannot interleave.
+func (t *TaskUsecase) setChecklist(ctx context.Context, actor Domain.Actor, existing Domain.Task, checklist []Domain.ChecklistItem, ifVersion *int64) (Domain.Task, error) {
+	changes := Domain.TaskChanges{
+		Set:       map[string]interface{}{},
+		IfVersion: &existing.Version,
+		UpdatedBy: actor.Username,
+	}
+	setOrUnset(&changes, "checklist", checklist, len(checklist) == 0)
+	updated, err := t.apply(ctx, actor, Domain.HistoryUpdated, existing, changes)
+	return updated, raced(err, ifVersion)
+}
+
+func checklistIndex(items []Domain.ChecklistItem, itemID string) (int, error) {
+	oid, err := primitive.ObjectIDFromHex(itemID)
+	if err != nil {
+		return -1, Domain.ErrChecklistItemNotFound
+	}
+	for i, item := range items {
+		if item.ID == oid {
+			return i, nil
+		}
+	}
+	return -1, Domain.ErrChecklistItemNotFound
+}
@@ -0,0 +1,126 @@
+package Usecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"task_manager1/Domain"
+)
+
+const (
+	// maxCommentLength bounds a comment body in characters.
+	maxCommentLength = 10000
+	// maxMentions bounds how many users one comment can mention.
+	maxMentions = 20
+)
+
+// Comments lists the thread of a task the actor can see, oldest first. It
+// returns nil when the task is not visible.
+func (t *TaskUsecase) Comments(ctx context.Context, actor Domain.Actor, taskID string) ([]Domain.Comment, error) {
+	task, err := t.visible(ctx, actor, taskID)
+	if err != nil || task.ID.IsZero() {
+		return nil, err
+	}
+	return t.comments.ListByTask(ctx, task.ID)
+}
+
+// AddComment posts a comment on a task the actor can see. It returns an
+// empty comment when the task is not visible.
+func (t *TaskUsecase) AddComment(ctx context.Context, actor Domain.Actor, taskID string, input Domain.CommentInput) (Domain.Comment, error) {
+	if actor.UserID.IsZero() {
+		return Domain.Comment{}, Domain.ErrForbidden
+	}
+	task, p, err := t.load(ctx, actor, taskID)
+	if err != nil || task.ID.IsZero() {
+		return Domain.Comment{}, err
+	}
+	body, mentions, err := t.commentBody(ctx, task, p, input.Body)
+	if err != nil {
+		return Domain.Comment{}, err
+	}
+	return t.comments.Create(ctx, Domain.Comment{
+		TaskID:   task.ID,
+		AuthorID: actor.UserID,
+		Author:   actor.Username,
+		Body:     body,
+		Mentions: mentions,
+	})
+}
+
+// EditComment replaces the body of a comment. Only its author may edit it.
+func (t *TaskUsecase) EditComment(ctx context.Context, actor Domain.Actor, taskID, commentID string, input Domain.CommentInput) (Domain.Comment, error) {
+	task, p, err := t.load(ctx, actor, taskID)
+	if err != nil || task.ID.IsZero() {
+		return Domain.Comment{}, err
+	}
+	c, err := t.comment(ctx, task, commentID)
+	if err != nil || c.ID.IsZero() {
+		return Domain.Comment{}, err
+	}
+	if !c.CanEdit(actor) {
+		return Domain.Comment{}, Domain.ErrNotCommentAuthor
+	}
+	body, mentions, err := t.commentBody(ctx, task, p, input.Body)
+	if err != nil {
+		return Domain.Comment{}, err
+	}
+	return t.comments.UpdateBody(ctx, c.ID, body, mentions)
+}
+
+// DeleteComment removes a comment; authors can delete their own and admins any.
+func (t *TaskUsecase) DeleteComment(ctx context.Context, actor Domain.Actor, taskID, commentID string) (bool, error) {
+	task, err := t.visible(ctx, actor, taskID)
+	if err != nil || task.ID.IsZero() {
+		return false, err
+	}
+	c, err := t.comment(ctx, task, commentID)
+	if err != nil || c.ID.IsZero() {
+		return false, err
+	}
+	if !c.CanDelete(actor) {
+		return false, Domain.ErrForbidden
+	}
+	return t.comments.Delete(ctx, c.ID)
+}
+
+// comment loads a comment of task, or an empty comment when it does not
+// exist or belongs to another task.
+func (t *TaskUsecase) comment(ctx context.Context, task Domain.Task, commentID string) (Domain.Comment, error) {
+	c, err := t.comments.FindByID(ctx, commentID)
+	if err != nil || c.TaskID != task.ID {
+		return Domain.Comment{}, err
+	}
+	return c, nil
+}
+
+// commentBody validates a comment body and the users it mentions. Only users
+// who can see the task can be mentioned; anyone else is reported as unknown,
+// so a mention does not reveal the task to them or them to the author.
+func (t *TaskUsecase) commentBody(ctx context.Context, task Domain.Task, p Domain.Project, body string) (string, []string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", nil, fmt.Errorf("%w: body required", Domain.ErrInvalidComment)
+	}
+	if utf8.RuneCountInString(body) > maxCommentLength {
+		return "", nil, fmt.Errorf("%w: at most %d characters", Domain.ErrInvalidComment, maxCommentLength)
+	}
+	mentions := Domain.ParseMentions(body)
+	if len(mentions) > maxMentions {
+		return "", nil, fmt.Errorf("%w: at most %d mentions", Domain.ErrInvalidComment, maxMentions)
+	}
+	for _, name := range mentions {
+		u, err := t.users.FindByUsername(ctx, name)
+		if err != nil {
+			return "", nil, err
+		}
+		if u.Username == "" || !task.CanView(Domain.Actor{UserID: u.ID, Username: u.Username, Role: u.Role}, p) {
+			return "", nil, fmt.Errorf("%w: @%s", Domain.ErrUnknownMention, name)
+		}
+	}
+	if len(mentions) == 0 {
+		mentions = nil
+	}
+	return body, mentions, nil
+}
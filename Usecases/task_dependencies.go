@@ -0,0 +1,272 @@
+package Usecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"task_manager1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// maxGraphNodes bounds the graph returned by Graph.
+	maxGraphNodes = 200
+	// maxCycleSearch bounds how many tasks a cycle check may visit.
+	maxCycleSearch = 5000
+)
+
+// AddDependency records that blockerID blocks task id. Both tasks must be
+// visible to the actor, who must be able to modify task id, and the link
+// must not close a cycle. Two concurrent links can each pass the check and
+// together close one, so the graph is checked again after the write and the
+// link is taken back when it did; whichever link is written last sees the
+// other, so the cycle never stays.
+func (t *TaskUsecase) AddDependency(ctx context.Context, actor Domain.Actor, id, blockerID string) (Domain.Task, error) {
+	existing, err := t.editable(ctx, actor, id, nil)
+	if err != nil || existing.ID.IsZero() {
+		return Domain.Task{}, err
+	}
+	blocker, err := t.visible(ctx, actor, blockerID)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	if blocker.ID.IsZero() {
+		return Domain.Task{}, fmt.Errorf("%w: blocking task not found", Domain.ErrInvalidDependency)
+	}
+	if blocker.ID == existing.ID {
+		return Domain.Task{}, fmt.Errorf("%w: a task cannot block itself", Domain.ErrInvalidDependency)
+	}
+	if existing.IsBlockedBy(blocker.ID) {
+		return t.annotated(ctx, existing)
+	}
+	if err := t.checkNoCycle(ctx, existing.ID, blocker); err != nil {
+		return Domain.Task{}, err
+	}
+	updated, err := t.repo.Update(ctx, existing.ID.Hex(), Domain.TaskChanges{
+		AddToSet:  map[string]interface{}{"blocked_by": blocker.ID},
+		IfVersion: &existing.Version,
+		UpdatedBy: actor.Username,
+	})
+	if err != nil || updated.ID.IsZero() {
+		return updated, raced(err, nil)
+	}
+	if err := t.recheckNoCycle(ctx, existing.ID, blocker.ID); err != nil {
+		if _, uerr := t.repo.Update(ctx, existing.ID.Hex(), Domain.TaskChanges{
+			Pull:      map[string]interface{}{"blocked_by": blocker.ID},
+			UpdatedBy: actor.Username,
+		}); uerr != nil {
+			return Domain.Task{}, uerr
+		}
+		return Domain.Task{}, err
+	}
+	t.recordCommitted(ctx, actor, Domain.HistoryUpdated, existing, updated)
+	return t.annotated(ctx, updated)
+}
+
+// recheckNoCycle runs checkNoCycle against the current state of blockerID,
+// once the link from task id to it has been written.
+func (t *TaskUsecase) recheckNoCycle(ctx context.Context, id, blockerID primitive.ObjectID) error {
+	current, err := t.repo.FindByIDs(ctx, []primitive.ObjectID{blockerID})
+	if err != nil || len(current) == 0 {
+		return err
+	}
+	return t.checkNoCycle(ctx, id, current[0])
+}
+
+// RemoveDependency drops blockerID from the blockers of task id.
+func (t *TaskUsecase) RemoveDependency(ctx context.Context, actor Domain.Actor, id, blockerID string) (Domain.Task, error) {
+	existing, err := t.editable(ctx, actor, id, nil)
+	if err != nil || existing.ID.IsZero() {
+		return Domain.Task{}, err
+	}
+	oid, err := primitive.ObjectIDFromHex(blockerID)
+	if err != nil || !existing.IsBlockedBy(oid) {
+		return Domain.Task{}, Domain.ErrDependencyNotFound
+	}
+	updated, err := t.apply(ctx, actor, Domain.HistoryUpdated, existing, Domain.TaskChanges{
+		Pull:      map[string]interface{}{"blocked_by": oid},
+		IfVersion: &existing.Version,
+		UpdatedBy: actor.Username,
+	})
+	return updated, raced(err, nil)
+}
+
+// checkNoCycle fails when task id is among the tasks blocker already waits
+// on, directly or transitively. Every task counts here, hidden or trashed,
+// since either can come back into play.
+func (t *TaskUsecase) checkNoCycle(ctx context.Context, id primitive.ObjectID, blocker Domain.Task) error {
+	seen := map[primitive.ObjectID]bool{blocker.ID: true}
+	frontier := blocker.BlockedBy
+	for len(frontier) > 0 {
+		next := []primitive.ObjectID{}
+		for _, oid := range frontier {
+			if oid == id {
+				return Domain.ErrDependencyCycle
+			}
+			if !seen[oid] {
+				seen[oid] = true
+				next = append(next, oid)
+			}
+		}
+		if len(seen) > maxCycleSearch {
+			return fmt.Errorf("%w: dependency chain is too long", Domain.ErrInvalidDependency)
+		}
+		tasks, err := t.repo.FindByIDs(ctx, next)
+		if err != nil {
+			return err
+		}
+		frontier = nil
+		for _, task := range tasks {
+			frontier = append(frontier, task.BlockedBy...)
+		}
+	}
+	return nil
+}
+
+// checkStatusChange validates a status change against the workflow and
+// refuses to complete a task while it is blocked.
+func (t *TaskUsecase) checkStatusChange(ctx context.Context, existing Domain.Task, to string) error {
+	if err := t.checkTransition(existing.Status, to); err != nil {
+		return err
+	}
+	if to != Domain.StatusDone || existing.Status == Domain.StatusDone || len(existing.BlockedBy) == 0 {
+		return nil
+	}
+	blockers, err := t.repo.FindByIDs(ctx, existing.BlockedBy)
+	if err != nil {
+		return err
+	}
+	open := []string{}
+	for _, b := range blockers {
+		if blocks(b) {
+			open = append(open, b.ID.Hex())
+		}
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("%w: %s", Domain.ErrTaskBlocked, strings.Join(open, ", "))
+	}
+	return nil
+}
+
+// blocks reports whether b still holds up the tasks waiting on it.
+func blocks(b Domain.Task) bool {
+	return !b.IsDeleted() && b.Status != Domain.StatusDone
+}
+
+// markBlocked sets the Blocked flag of tasks in place.
+func (t *TaskUsecase) markBlocked(ctx context.Context, tasks []Domain.Task) error {
+	ids := []primitive.ObjectID{}
+	seen := map[primitive.ObjectID]bool{}
+	for _, task := range tasks {
+		for _, b := range task.BlockedBy {
+			if !seen[b] {
+				seen[b] = true
+				ids = append(ids, b)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	blockers, err := t.repo.FindByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	open := map[primitive.ObjectID]bool{}
+	for _, b := range blockers {
+		open[b.ID] = blocks(b)
+	}
+	for i := range tasks {
+		tasks[i].Blocked = false
+		for _, b := range tasks[i].BlockedBy {
+			if open[b] {
+				tasks[i].Blocked = true
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// Graph returns every task id transitively waits on or holds up. Traversal
+// stops at tasks the actor cannot see, and the graph is cut off after
+// maxGraphNodes tasks. An empty Root means the task is not visible.
+func (t *TaskUsecase) Graph(ctx context.Context, actor Domain.Actor, id string) (Domain.TaskGraph, error) {
+	root, err := t.visible(ctx, actor, id)
+	if err != nil || root.ID.IsZero() {
+		return Domain.TaskGraph{}, err
+	}
+	g := Domain.TaskGraph{Root: root.ID}
+	nodes := map[primitive.ObjectID]Domain.Task{root.ID: root}
+	order := []primitive.ObjectID{root.ID}
+	projects := map[primitive.ObjectID]Domain.Project{}
+	add := func(tasks []Domain.Task) ([]Domain.Task, error) {
+		added := []Domain.Task{}
+		for _, task := range tasks {
+			if _, ok := nodes[task.ID]; ok || task.IsDeleted() {
+				continue
+			}
+			p, err := t.projectOf(ctx, task, projects)
+			if err != nil {
+				return nil, err
+			}
+			if !task.CanView(actor, p) {
+				continue
+			}
+			if len(nodes) >= maxGraphNodes {
+				g.Truncated = true
+				break
+			}
+			nodes[task.ID] = task
+			order = append(order, task.ID)
+			added = append(added, task)
+		}
+		return added, nil
+	}
+
+	// upstream: the tasks root waits on
+	for frontier := []Domain.Task{root}; len(frontier) > 0; {
+		ids := []primitive.ObjectID{}
+		for _, task := range frontier {
+			ids = append(ids, task.BlockedBy...)
+		}
+		found, err := t.repo.FindByIDs(ctx, ids)
+		if err != nil {
+			return Domain.TaskGraph{}, err
+		}
+		if frontier, err = add(found); err != nil {
+			return Domain.TaskGraph{}, err
+		}
+	}
+	// downstream: the tasks waiting on root
+	for frontier := []Domain.Task{root}; len(frontier) > 0; {
+		ids := []primitive.ObjectID{}
+		for _, task := range frontier {
+			ids = append(ids, task.ID)
+		}
+		found, err := t.repo.FindBlockedBy(ctx, ids)
+		if err != nil {
+			return Domain.TaskGraph{}, err
+		}
+		if frontier, err = add(found); err != nil {
+			return Domain.TaskGraph{}, err
+		}
+	}
+
+	for _, oid := range order {
+		g.Nodes = append(g.Nodes, nodes[oid])
+	}
+	if err := t.markBlocked(ctx, g.Nodes); err != nil {
+		return Domain.TaskGraph{}, err
+	}
+	for _, task := range g.Nodes {
+		for _, b := range task.BlockedBy {
+			if _, ok := nodes[b]; ok {
+				g.Edges = append(g.Edges, Domain.DependencyEdge{From: b, To: task.ID})
+			}
+		}
+	}
+	return g, nil
+}
@@ -0,0 +1,150 @@
+package Usecases
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"sort"
+	"time"
+
+	"task_manager1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// untrackedFields change on every write and are already captured by the
+// history entry itself, so they are left out of field diffs.
+var untrackedFields = map[string]bool{
+	"_id":        true,
+	"version":    true,
+	"updated_at": true,
+	"updated_by": true,
+}
+
+// diffTasks lists the fields that differ between two versions of a task,
+// keyed by their stored (BSON) names so new task fields are tracked too.
+func diffTasks(before, after Domain.Task) ([]Domain.FieldChange, error) {
+	b, err := taskFields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := taskFields(after)
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for k := range b {
+		names[k] = true
+	}
+	for k := range a {
+		names[k] = true
+	}
+	changes := []Domain.FieldChange{}
+	for name := range names {
+		if untrackedFields[name] || reflect.DeepEqual(b[name], a[name]) {
+			continue
+		}
+		changes = append(changes, Domain.FieldChange{Field: name, Before: b[name], After: a[name]})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+func taskFields(t Domain.Task) (bson.M, error) {
+	if t.ID.IsZero() && t.Title == "" {
+		return bson.M{}, nil
+	}
+	raw, err := bson.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	var m bson.M
+	err = bson.Unmarshal(raw, &m)
+	return m, err
+}
+
+// record appends a history entry describing the change from before to after.
+// When after is empty the task is gone for good and the snapshot is its last state.
+func (t *TaskUsecase) record(ctx context.Context, actor Domain.Actor, action string, before, after Domain.Task) error {
+	snapshot := after
+	var changes []Domain.FieldChange
+	if after.ID.IsZero() {
+		snapshot = before
+	} else {
+		var err error
+		if changes, err = diffTasks(before, after); err != nil {
+			return err
+		}
+	}
+	return t.history.Record(ctx, Domain.TaskHistoryEntry{
+		TaskID:   snapshot.ID,
+		TenantID: snapshot.TenantID,
+		Action:   action,
+		Actor:    actor.Username,
+		At:       time.Now().UTC().Truncate(time.Millisecond),
+		Version:  snapshot.Version,
+		Changes:  changes,
+		Snapshot: snapshot,
+	})
+}
+
+// recordCommitted records a change that has already been written. The write
+// cannot be taken back, so a history failure is logged instead of failing a
+// request whose change went through.
+func (t *TaskUsecase) recordCommitted(ctx context.Context, actor Domain.Actor, action string, before, after Domain.Task) {
+	if err := t.record(ctx, actor, action, before, after); err != nil {
+		id := after.ID
+		if id.IsZero() {
+			id = before.ID
+		}
+		log.Printf("history: recording %s of task %s: %v", action, id.Hex(), err)
+	}
+}
+
+// History returns every recorded change of a task, oldest first. Deleted
+// tasks keep their history; visibility is then judged on the last snapshot.
+func (t *TaskUsecase) History(ctx context.Context, actor Domain.Actor, id string) ([]Domain.TaskHistoryEntry, error) {
+	entries, err := t.history.ListByTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		// tasks created before history was recorded
+		task, err := t.GetByID(ctx, actor, id)
+		if err != nil || task.ID.IsZero() {
+			return nil, err
+		}
+		return []Domain.TaskHistoryEntry{}, nil
+	}
+	if !t.canSeeHistory(ctx, actor, id, entries[len(entries)-1]) {
+		return nil, nil
+	}
+	return entries, nil
+}
+
+// StateAt returns the history entry describing the task as it was at the
+// given time. An empty entry means the task did not exist yet or is hidden.
+func (t *TaskUsecase) StateAt(ctx context.Context, actor Domain.Actor, id string, at time.Time) (Domain.TaskHistoryEntry, error) {
+	e, err := t.history.AsOf(ctx, id, at)
+	if err != nil || e.TaskID.IsZero() {
+		return Domain.TaskHistoryEntry{}, err
+	}
+	if !t.canSeeHistory(ctx, actor, id, e) {
+		return Domain.TaskHistoryEntry{}, nil
+	}
+	return e, nil
+}
+
+// canSeeHistory checks visibility on the live task, falling back to the
+// snapshot in e when the task no longer exists.
+func (t *TaskUsecase) canSeeHistory(ctx context.Context, actor Domain.Actor, id string, e Domain.TaskHistoryEntry) bool {
+	task := e.Snapshot
+	if current, err := t.repo.FindByID(ctx, id); err == nil && !current.ID.IsZero() {
+		task = current
+	}
+	p, err := t.projectOf(ctx, task, nil)
+	if err != nil {
+		return false
+	}
+	return task.CanView(actor, p)
+}
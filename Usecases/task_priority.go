@@ -0,0 +1,89 @@
+package Usecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"task_manager1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxBulkPriorityTasks bounds how many tasks one re-prioritisation touches.
+const maxBulkPriorityTasks = 100
+
+// checkPriority validates a priority, defaulting an empty one.
+func checkPriority(priority string) (string, error) {
+	if priority == "" {
+		return Domain.DefaultPriority, nil
+	}
+	if Domain.PriorityRank(priority) == 0 {
+		return "", fmt.Errorf("%w, got %q", Domain.ErrInvalidPriority, priority)
+	}
+	return priority, nil
+}
+
+// setPriority writes priority and its rank to changes.
+func setPriority(changes *Domain.TaskChanges, priority string) {
+	changes.Set["priority"] = priority
+	changes.Set["priority_rank"] = Domain.PriorityRank(priority)
+}
+
+// Reprioritize gives every listed task the same priority. It is limited to
+// admins. Every task is read before any is written, so a request that fails
+// changes nothing; after that each task is updated and recorded in its
+// history on its own, and one that cannot be written is reported as failed
+// without stopping the rest. Ids that are not valid task ids are reported as
+// not found.
+func (t *TaskUsecase) Reprioritize(ctx context.Context, actor Domain.Actor, update Domain.PriorityUpdate) (Domain.PriorityUpdateResult, error) {
+	result := Domain.PriorityUpdateResult{Updated: []string{}, Unchanged: []string{}, NotFound: []string{}, Failed: []string{}}
+	if !actor.IsAdmin() {
+		return result, Domain.ErrForbidden
+	}
+	if update.Priority == "" {
+		return result, Domain.ErrInvalidPriority
+	}
+	priority, err := checkPriority(update.Priority)
+	if err != nil {
+		return result, err
+	}
+	if len(update.TaskIDs) == 0 || len(update.TaskIDs) > maxBulkPriorityTasks {
+		return result, fmt.Errorf("%w: task_ids must list 1 to %d tasks", Domain.ErrInvalidQuery, maxBulkPriorityTasks)
+	}
+	pending := []Domain.Task{}
+	for _, id := range update.TaskIDs {
+		if !primitive.IsValidObjectID(id) {
+			result.NotFound = append(result.NotFound, id)
+			continue
+		}
+		existing, err := t.editable(ctx, actor, id, nil)
+		if err != nil {
+			return Domain.PriorityUpdateResult{}, err
+		}
+		switch {
+		case existing.ID.IsZero():
+			result.NotFound = append(result.NotFound, id)
+		case existing.Priority == priority:
+			result.Unchanged = append(result.Unchanged, id)
+		default:
+			pending = append(pending, existing)
+		}
+	}
+	for _, existing := range pending {
+		id := existing.ID.Hex()
+		changes := Domain.TaskChanges{Set: map[string]interface{}{}, IfVersion: &existing.Version, UpdatedBy: actor.Username}
+		setPriority(&changes, priority)
+		updated, err := t.apply(ctx, actor, Domain.HistoryUpdated, existing, changes)
+		switch {
+		case err != nil:
+			log.Printf("priority: updating task %s: %v", id, err)
+			result.Failed = append(result.Failed, id)
+		case updated.ID.IsZero():
+			result.NotFound = append(result.NotFound, id)
+		default:
+			result.Updated = append(result.Updated, id)
+		}
+	}
+	return result, nil
+}
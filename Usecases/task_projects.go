@@ -0,0 +1,76 @@
+package Usecases
+
+import (
+	"context"
+
+	"task_manager1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// projectOf returns the project of task, or an empty project for tasks not
+// yet moved into one. cache, when not nil, memoises lookups across tasks.
+func (t *TaskUsecase) projectOf(ctx context.Context, task Domain.Task, cache map[primitive.ObjectID]Domain.Project) (Domain.Project, error) {
+	if task.ProjectID.IsZero() {
+		return Domain.Project{}, nil
+	}
+	if p, ok := cache[task.ProjectID]; ok {
+		return p, nil
+	}
+	p, err := t.projects.FindByID(ctx, task.ProjectID.Hex())
+	if err != nil {
+		return Domain.Project{}, err
+	}
+	if cache != nil {
+		cache[task.ProjectID] = p
+	}
+	return p, nil
+}
+
+// memberOf lists the ids of the projects the actor belongs to.
+func (t *TaskUsecase) memberOf(ctx context.Context, actor Domain.Actor) ([]primitive.ObjectID, error) {
+	projects, err := t.projects.ListByMember(ctx, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(projects))
+	for _, p := range projects {
+		ids = append(ids, p.ID)
+	}
+	return ids, nil
+}
+
+// targetProject resolves the project a task is created in or moved to: id
+// when set, otherwise the actor's personal project. The actor must be
+// allowed to edit tasks there.
+func (t *TaskUsecase) targetProject(ctx context.Context, actor Domain.Actor, id primitive.ObjectID) (primitive.ObjectID, error) {
+	if id.IsZero() {
+		p, err := t.projects.EnsurePersonal(ctx, Domain.ProjectMember{UserID: actor.UserID, Username: actor.Username})
+		return p.ID, err
+	}
+	p, err := t.projects.FindByID(ctx, id.Hex())
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	if p.ID.IsZero() || !p.CanView(actor) {
+		return primitive.NilObjectID, Domain.ErrProjectNotFound
+	}
+	if !p.CanEdit(actor) {
+		return primitive.NilObjectID, Domain.ErrForbidden
+	}
+	return p.ID, nil
+}
+
+// moveTo records in changes a move of existing to project id. A zero id or
+// the current project leaves the task where it is.
+func (t *TaskUsecase) moveTo(ctx context.Context, actor Domain.Actor, existing Domain.Task, id primitive.ObjectID, changes *Domain.TaskChanges) error {
+	if id.IsZero() || id == existing.ProjectID {
+		return nil
+	}
+	target, err := t.targetProject(ctx, actor, id)
+	if err != nil {
+		return err
+	}
+	changes.Set["project_id"] = target
+	return nil
+}
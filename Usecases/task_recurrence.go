@@ -0,0 +1,190 @@
+package Usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"task_manager1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// materializeBatch is how many series the materialiser loads at a time.
+	materializeBatch = 500
+	// maxMaterializeRounds bounds one materialiser run.
+	maxMaterializeRounds = 100
+)
+
+// recurrenceFor validates a client supplied rule for a task due at due.
+// Keeping the rule of existing keeps its series; a new or changed rule starts
+// a new series at due, evaluated in the actor's time zone. An empty rule
+// means the task does not recur.
+func (t *TaskUsecase) recurrenceFor(ctx context.Context, actor Domain.Actor, existing Domain.Task, rule string, due *time.Time) (*Domain.TaskRecurrence, error) {
+	if rule == "" {
+		return nil, nil
+	}
+	r, err := Domain.ParseRRule(rule)
+	if err != nil {
+		return nil, err
+	}
+	if due == nil {
+		return nil, fmt.Errorf("%w: recurring tasks need a due date", Domain.ErrInvalidRecurrence)
+	}
+	if existing.Recurrence != nil && existing.Recurrence.Rule == r.String() {
+		return existing.Recurrence, nil
+	}
+	loc, err := t.Location(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+	return &Domain.TaskRecurrence{
+		Rule:     r.String(),
+		Start:    *due,
+		Timezone: loc.String(),
+		SeriesID: primitive.NewObjectID(),
+		Index:    1,
+	}, nil
+}
+
+// nextOccurrence returns the due date of the occurrence following task, or
+// false when its series has ended.
+func nextOccurrence(task Domain.Task) (time.Time, bool, error) {
+	rec := task.Recurrence
+	if rec == nil || task.DueDate == nil {
+		return time.Time{}, false, nil
+	}
+	r, err := Domain.ParseRRule(rec.Rule)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	loc, err := LoadTimezone(rec.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	next, ok := r.Next(rec.Start, *task.DueDate, rec.Index, loc)
+	return next, ok, nil
+}
+
+// continueSeries creates the occurrence following task unless it exists
+// already, then marks task as continued. A series that has ended is only marked.
+func (t *TaskUsecase) continueSeries(ctx context.Context, actor Domain.Actor, task Domain.Task) error {
+	due, ok, err := nextOccurrence(task)
+	if err != nil {
+		return err
+	}
+	if ok {
+		if err := t.createOccurrence(ctx, actor, task, due); err != nil {
+			return err
+		}
+	}
+	return t.repo.MarkNextCreated(ctx, task.ID)
+}
+
+// createOccurrence copies the client visible fields of task into a new open
+// occurrence due at due. Checklist items start unchecked; dependencies are
+// specific to one occurrence and are not copied.
+func (t *TaskUsecase) createOccurrence(ctx context.Context, actor Domain.Actor, task Domain.Task, due time.Time) error {
+	rec := *task.Recurrence
+	rec.Index++
+	rec.NextCreated = false
+	next := Domain.Task{
+		Title:        task.Title,
+		Description:  task.Description,
+		DueDate:      &due,
+		Status:       t.workflow.Initial,
+		Priority:     task.Priority,
+		PriorityRank: task.PriorityRank,
+		CreatorID:    task.CreatorID,
+		OwnerID:      task.OwnerID,
+		ProjectID:    task.ProjectID,
+		TenantID:     task.TenantID,
+		SharedWith:   task.SharedWith,
+		Assignees:    task.Assignees,
+		ParentID:     task.ParentID,
+		Tags:         task.Tags,
+		CreatedBy:    actor.Username,
+		Recurrence:   &rec,
+	}
+	for _, item := range task.Checklist {
+		next.Checklist = append(next.Checklist, Domain.ChecklistItem{ID: primitive.NewObjectID(), Text: item.Text})
+	}
+	created, err := t.repo.Create(ctx, next)
+	if errors.Is(err, Domain.ErrOccurrenceExists) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	t.recordCommitted(ctx, actor, Domain.HistoryCreated, Domain.Task{}, created)
+	return nil
+}
+
+// completed continues the series of a recurring task that was just marked done.
+func (t *TaskUsecase) completed(ctx context.Context, actor Domain.Actor, before, after Domain.Task) {
+	if after.Recurrence == nil || after.Recurrence.NextCreated ||
+		after.Status != Domain.StatusDone || before.Status == Domain.StatusDone {
+		return
+	}
+	if err := t.continueSeries(ctx, actor, after); err != nil {
+		// the materialiser retries series that were not continued
+		log.Printf("recurrence: continuing series of task %s: %v", after.ID.Hex(), err)
+	}
+}
+
+// MaterializeOccurrences creates every occurrence of recurring series due
+// within horizon from now and returns how many it created. Tails are paged
+// by id, so series whose next occurrence lies beyond the horizon are passed
+// over instead of filling every batch; occurrences created along the way
+// get newer ids and are continued in later pages.
+func (t *TaskUsecase) MaterializeOccurrences(ctx context.Context, horizon time.Duration) (int, error) {
+	until := time.Now().Add(horizon)
+	created := 0
+	after := primitive.NilObjectID
+	for round := 0; round < maxMaterializeRounds; round++ {
+		tails, err := t.repo.FindRecurring(Domain.AllTenants(ctx), until, after, materializeBatch)
+		if err != nil || len(tails) == 0 {
+			return created, err
+		}
+		for _, tail := range tails {
+			after = tail.ID
+			due, ok, err := nextOccurrence(tail)
+			if err != nil {
+				return created, err
+			}
+			if ok && due.After(until) {
+				continue
+			}
+			if err := t.continueSeries(Domain.WithTenant(ctx, tail.TenantID), systemActor, tail); err != nil {
+				return created, err
+			}
+			if ok {
+				created++
+			}
+		}
+	}
+	return created, nil
+}
+
+// RunRecurrenceMaterializer calls MaterializeOccurrences every interval until
+// ctx is cancelled.
+func (t *TaskUsecase) RunRecurrenceMaterializer(ctx context.Context, interval, horizon time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := t.MaterializeOccurrences(ctx, horizon)
+		if err != nil {
+			log.Printf("recurrence materialiser error: %v", err)
+		} else if n > 0 {
+			log.Printf("recurrence materialiser: %d occurrences created", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package Usecases
+
+import (
+	"context"
+	"fmt"
+
+	"task_manager1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxTaskDepth bounds how deeply subtasks may be nested.
+const maxTaskDepth = 10
+
+// checkParent validates parentID as the parent of task id (zero for a new
+// task): the parent must be a live task the actor can see, and linking must
+// not create a cycle or nest the task, or any of its own subtasks, deeper
+// than maxTaskDepth.
+func (t *TaskUsecase) checkParent(ctx context.Context, actor Domain.Actor, id, parentID primitive.ObjectID) error {
+	if parentID.IsZero() {
+		return nil
+	}
+	if parentID == id {
+		return fmt.Errorf("%w: a task cannot be its own parent", Domain.ErrInvalidParent)
+	}
+	parent, err := t.visible(ctx, actor, parentID.Hex())
+	if err != nil {
+		return err
+	}
+	if parent.ID.IsZero() {
+		return fmt.Errorf("%w: parent task not found", Domain.ErrInvalidParent)
+	}
+	tooDeep := fmt.Errorf("%w: subtasks cannot be nested more than %d levels deep", Domain.ErrInvalidParent, maxTaskDepth)
+	depth := 1
+	for ancestor := parent; !ancestor.ParentID.IsZero(); depth++ {
+		if ancestor.ParentID == id {
+			return fmt.Errorf("%w: a task cannot be nested under its own subtask", Domain.ErrInvalidParent)
+		}
+		if depth >= maxTaskDepth {
+			return tooDeep
+		}
+		if ancestor, err = t.repo.FindByID(ctx, ancestor.ParentID.Hex()); err != nil {
+			return err
+		}
+	}
+	if id.IsZero() {
+		return nil
+	}
+	// the task's own subtasks move down with it
+	height, err := t.subtreeHeight(ctx, id, maxTaskDepth-depth)
+	if err != nil {
+		return err
+	}
+	if depth+height > maxTaskDepth {
+		return tooDeep
+	}
+	return nil
+}
+
+// subtreeHeight returns how many levels of subtasks hang below task id. It
+// stops looking once the height exceeds limit.
+func (t *TaskUsecase) subtreeHeight(ctx context.Context, id primitive.ObjectID, limit int) (int, error) {
+	level := []primitive.ObjectID{id}
+	height := 0
+	for height <= limit {
+		children, err := t.repo.FindSubtasks(ctx, level)
+		if err != nil {
+			return 0, err
+		}
+		if len(children) == 0 {
+			break
+		}
+		height++
+		level = make([]primitive.ObjectID, 0, len(children))
+		for _, child := range children {
+			level = append(level, child.ID)
+		}
+	}
+	return height, nil
+}
+
+// countSubtasks fills in the subtask counts of tasks in place.
+func (t *TaskUsecase) countSubtasks(ctx context.Context, tasks []Domain.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	ids := make([]primitive.ObjectID, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	counts, err := t.repo.CountSubtasks(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range tasks {
+		tasks[i].Subtasks = counts[tasks[i].ID]
+	}
+	return nil
+}
+
+// Children lists the visible subtasks of a task, oldest first unless f asks
+// for another order. It returns nil tasks when the parent is not visible.
+func (t *TaskUsecase) Children(ctx context.Context, actor Domain.Actor, id string, f Domain.TaskFilter) (Domain.TaskPage, error) {
+	parent, err := t.visible(ctx, actor, id)
+	if err != nil || parent.ID.IsZero() {
+		return Domain.TaskPage{}, err
+	}
+	f.ParentID = parent.ID
+	if f.SortBy == "" {
+		f.SortBy = Domain.SortByCreatedAt
+	}
+	page, err := t.List(ctx, actor, f)
+	if err == nil && page.Tasks == nil {
+		page.Tasks = []Domain.Task{}
+	}
+	return page, err
+}
@@ -0,0 +1,193 @@
+package Usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"task_manager1/Domain"
+)
+
+const (
+	// maxTaskTags bounds how many tags one task can carry.
+	maxTaskTags = 20
+	// retagBatch is how many tasks a catalogue change rewrites at a time.
+	retagBatch = 500
+)
+
+// checkTags normalises the tags given for a task, dropping duplicates, and
+// makes sure each of them is in the catalogue.
+func (t *TaskUsecase) checkTags(ctx context.Context, names []string) ([]string, error) {
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		tag, err := Domain.NormalizeTag(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	if len(tags) > maxTaskTags {
+		return nil, fmt.Errorf("%w: a task can have at most %d tags", Domain.ErrInvalidTag, maxTaskTags)
+	}
+	known, err := t.tags.FindByNames(ctx, tags)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range known {
+		delete(seen, k.Name)
+	}
+	for _, tag := range tags {
+		if seen[tag] {
+			return nil, fmt.Errorf("%w %q", Domain.ErrUnknownTag, tag)
+		}
+	}
+	return tags, nil
+}
+
+// normalizeTagFilter validates the tag filter of a task listing.
+func normalizeTagFilter(f *Domain.TaskFilter) error {
+	switch f.TagMatch {
+	case "", Domain.TagMatchAll, Domain.TagMatchAny:
+	default:
+		return fmt.Errorf("%w: tag_match must be %q or %q", Domain.ErrInvalidQuery, Domain.TagMatchAll, Domain.TagMatchAny)
+	}
+	if len(f.Tags) == 0 {
+		return nil
+	}
+	tags := make([]string, 0, len(f.Tags))
+	for _, name := range f.Tags {
+		tag, err := Domain.NormalizeTag(name)
+		if err != nil {
+			return fmt.Errorf("%w: %v", Domain.ErrInvalidQuery, err)
+		}
+		tags = append(tags, tag)
+	}
+	f.Tags = tags
+	return nil
+}
+
+// Tags lists the tag catalogue by name.
+func (t *TaskUsecase) Tags(ctx context.Context) ([]Domain.Tag, error) {
+	return t.tags.List(ctx)
+}
+
+// Tag returns a catalogue entry, or an empty tag when it does not exist.
+func (t *TaskUsecase) Tag(ctx context.Context, id string) (Domain.Tag, error) {
+	return t.tags.FindByID(ctx, id)
+}
+
+// CreateTag adds a tag to the catalogue.
+func (t *TaskUsecase) CreateTag(ctx context.Context, actor Domain.Actor, input Domain.TagInput) (Domain.Tag, error) {
+	name, err := Domain.NormalizeTag(input.Name)
+	if err != nil {
+		return Domain.Tag{}, err
+	}
+	color, err := Domain.NormalizeTagColor(input.Color)
+	if err != nil {
+		return Domain.Tag{}, err
+	}
+	return t.tags.Create(ctx, Domain.Tag{Name: name, Color: color, CreatedBy: actor.Username})
+}
+
+// UpdateTag replaces the name and color of a tag. Renaming a tag renames it
+// on every task that carries it. The tasks are renamed before the catalogue,
+// so retrying a rename that failed half way finishes it.
+func (t *TaskUsecase) UpdateTag(ctx context.Context, actor Domain.Actor, id string, input Domain.TagInput) (Domain.Tag, error) {
+	name, err := Domain.NormalizeTag(input.Name)
+	if err != nil {
+		return Domain.Tag{}, err
+	}
+	color, err := Domain.NormalizeTagColor(input.Color)
+	if err != nil {
+		return Domain.Tag{}, err
+	}
+	existing, err := t.tags.FindByID(ctx, id)
+	if err != nil || existing.ID.IsZero() {
+		return Domain.Tag{}, err
+	}
+	if name != existing.Name {
+		taken, err := t.tags.FindByNames(ctx, []string{name})
+		if err != nil {
+			return Domain.Tag{}, err
+		}
+		if len(taken) > 0 {
+			return Domain.Tag{}, Domain.ErrTagExists
+		}
+		err = t.retag(ctx, actor, existing.Name, func(tags []string) []string {
+			renamed := []string{}
+			for _, tag := range tags {
+				if tag == existing.Name {
+					tag = name
+				}
+				if !containsString(renamed, tag) {
+					renamed = append(renamed, tag)
+				}
+			}
+			return renamed
+		})
+		if err != nil {
+			return Domain.Tag{}, err
+		}
+	}
+	return t.tags.Update(ctx, Domain.Tag{ID: existing.ID, Name: name, Color: color})
+}
+
+// DeleteTag removes a tag from every task and then from the catalogue, so
+// retrying a delete that failed half way finishes it.
+func (t *TaskUsecase) DeleteTag(ctx context.Context, actor Domain.Actor, id string) (bool, error) {
+	existing, err := t.tags.FindByID(ctx, id)
+	if err != nil || existing.ID.IsZero() {
+		return false, err
+	}
+	err = t.retag(ctx, actor, existing.Name, func(tags []string) []string {
+		kept := []string{}
+		for _, tag := range tags {
+			if tag != existing.Name {
+				kept = append(kept, tag)
+			}
+		}
+		return kept
+	})
+	if err != nil {
+		return false, err
+	}
+	return t.tags.Delete(ctx, existing.ID)
+}
+
+// retag rewrites the tags of every task carrying name with change. Each task
+// is written at the version it was read at and gets a history entry; a task
+// changed in the meantime is read again on the next pass.
+func (t *TaskUsecase) retag(ctx context.Context, actor Domain.Actor, name string, change func([]string) []string) error {
+	for {
+		tasks, err := t.repo.FindByTag(ctx, name, retagBatch)
+		if err != nil || len(tasks) == 0 {
+			return err
+		}
+		for _, task := range tasks {
+			changes := Domain.TaskChanges{
+				Set:       map[string]interface{}{},
+				IfVersion: &task.Version,
+				UpdatedBy: actor.Username,
+			}
+			tags := change(task.Tags)
+			setOrUnset(&changes, "tags", tags, len(tags) == 0)
+			updated, err := t.repo.Update(ctx, task.ID.Hex(), changes)
+			if errors.Is(err, Domain.ErrVersionMismatch) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if !updated.ID.IsZero() {
+				t.recordCommitted(ctx, actor, Domain.HistoryUpdated, task, updated)
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package Usecases
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"task_manager1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// systemActor is recorded for changes made by background jobs.
+var systemActor = Domain.Actor{Username: "system"}
+
+// Trash lists the deleted tasks visible to the actor, most recently deleted first
+// unless f asks for another order.
+func (t *TaskUsecase) Trash(ctx context.Context, actor Domain.Actor, f Domain.TaskFilter) (Domain.TaskPage, error) {
+	f.Trashed = true
+	if f.SortBy == "" {
+		f.SortBy = Domain.SortByDeletedAt
+		f.SortDesc = true
+	}
+	return t.List(ctx, actor, f)
+}
+
+// Restore takes a task out of the trash. Like Delete it is limited to
+// project owners, editors and admins.
+func (t *TaskUsecase) Restore(ctx context.Context, actor Domain.Actor, id string) (Domain.Task, error) {
+	existing, err := t.repo.FindByID(ctx, id)
+	if err != nil || existing.ID.IsZero() {
+		return Domain.Task{}, err
+	}
+	p, err := t.projectOf(ctx, existing, nil)
+	if err != nil || !existing.CanView(actor, p) {
+		return Domain.Task{}, err
+	}
+	if !existing.CanModify(actor, p) {
+		return Domain.Task{}, Domain.ErrForbidden
+	}
+	if !existing.IsDeleted() {
+		return Domain.Task{}, Domain.ErrNotInTrash
+	}
+	restored, err := t.apply(ctx, actor, Domain.HistoryRestored, existing, Domain.TaskChanges{
+		Unset:     []string{"deleted_at", "deleted_by"},
+		IfVersion: &existing.Version,
+		UpdatedBy: actor.Username,
+	})
+	return restored, raced(err, nil)
+}
+
+// PurgeTrash permanently removes tasks that have been in the trash for longer
+// than retention, together with their comments and attachments, and returns
+// how many were removed.
+func (t *TaskUsecase) PurgeTrash(ctx context.Context, retention time.Duration) (int, error) {
+	// the trash of every organisation is purged on the same schedule
+	ctx = Domain.AllTenants(ctx)
+	purged, err := t.repo.Purge(ctx, time.Now().Add(-retention))
+	ids := make([]primitive.ObjectID, 0, len(purged))
+	for _, task := range purged {
+		ids = append(ids, task.ID)
+		t.deleteBlobs(ctx, task.Attachments...)
+		if rerr := t.record(ctx, systemActor, Domain.HistoryPurged, task, Domain.Task{}); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	if _, cerr := t.comments.DeleteByTasks(ctx, ids); cerr != nil && err == nil {
+		err = cerr
+	}
+	return len(purged), err
+}
+
+// RunTrashPurger calls PurgeTrash every interval until ctx is cancelled.
+func (t *TaskUsecase) RunTrashPurger(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := t.PurgeTrash(ctx, retention)
+		if err != nil {
+			log.Printf("trash purge error: %v", err)
+		} else if n > 0 {
+			log.Printf("trash purge: %d tasks removed", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
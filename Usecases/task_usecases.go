@@ -2,40 +2,558 @@ package Usecases
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"time"
 
 	"task_manager1/Domain"
+	"task_manager1/Infrastructure/storage"
 	"task_manager1/Repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // TaskUsecase defines task business rules
 type TaskUsecase struct {
-	repo Repositories.TaskRepository
+	repo        Repositories.TaskRepository
+	users       Repositories.UserRepository
+	history     Repositories.HistoryRepository
+	tags        Repositories.TagRepository
+	comments    Repositories.CommentRepository
+	blobs       storage.BlobStore
+	projects    Repositories.ProjectRepository
+	workflow    Domain.Workflow
+	attachments Domain.AttachmentPolicy
+}
+
+func NewTaskUsecase(r Repositories.TaskRepository, users Repositories.UserRepository, history Repositories.HistoryRepository, tags Repositories.TagRepository, comments Repositories.CommentRepository, blobs storage.BlobStore, projects Repositories.ProjectRepository) *TaskUsecase {
+	return &TaskUsecase{
+		repo:        r,
+		users:       users,
+		history:     history,
+		tags:        tags,
+		comments:    comments,
+		blobs:       blobs,
+		projects:    projects,
+		workflow:    Domain.DefaultWorkflow(false),
+		attachments: Domain.DefaultAttachmentPolicy(),
+	}
 }
 
-func NewTaskUsecase(r Repositories.TaskRepository) *TaskUsecase {
-	return &TaskUsecase{repo: r}
+// SetWorkflow replaces the default status workflow.
+func (t *TaskUsecase) SetWorkflow(w Domain.Workflow) {
+	t.workflow = w
 }
 
-func (t *TaskUsecase) List(ctx context.Context) ([]Domain.Task, error) {
-	return t.repo.FindAll(ctx)
+// Workflow returns the status workflow enforced on task updates.
+func (t *TaskUsecase) Workflow() Domain.Workflow {
+	return t.workflow
 }
 
-func (t *TaskUsecase) GetByID(ctx context.Context, id string) (Domain.Task, error) {
-	return t.repo.FindByID(ctx, id)
+// checkTransition validates a status change against the workflow.
+func (t *TaskUsecase) checkTransition(from, to string) error {
+	if !t.workflow.IsState(to) {
+		return fmt.Errorf("%w %q", Domain.ErrUnknownStatus, to)
+	}
+	if from == to || t.workflow.CanTransition(from, to) {
+		return nil
+	}
+	return &Domain.TransitionError{From: from, To: to, Allowed: t.workflow.Next(from)}
+}
+
+// List returns a page of tasks matching f: every task for admins and, for
+// everyone else, the tasks of their projects and those shared with or
+// assigned to them. Unless f asks for another order, the most urgent tasks
+// come first and, within a priority, the soonest due.
+func (t *TaskUsecase) List(ctx context.Context, actor Domain.Actor, f Domain.TaskFilter) (Domain.TaskPage, error) {
+	switch f.SortBy {
+	case "", Domain.SortByCreatedAt, Domain.SortByUpdatedAt, Domain.SortByDueDate, Domain.SortByDeletedAt, Domain.SortByPriority:
+	default:
+		return Domain.TaskPage{}, fmt.Errorf("%w: unknown sort %q", Domain.ErrInvalidQuery, f.SortBy)
+	}
+	if f.Limit < 0 || f.Limit > Domain.MaxPageLimit {
+		return Domain.TaskPage{}, fmt.Errorf("%w: limit must be between 1 and %d", Domain.ErrInvalidQuery, Domain.MaxPageLimit)
+	}
+	if f.DueAfter != nil && f.DueBefore != nil && f.DueAfter.After(*f.DueBefore) {
+		return Domain.TaskPage{}, fmt.Errorf("%w: due_after is later than due_before", Domain.ErrInvalidQuery)
+	}
+	if f.Priority != "" && Domain.PriorityRank(f.Priority) == 0 {
+		return Domain.TaskPage{}, fmt.Errorf("%w: %v", Domain.ErrInvalidQuery, Domain.ErrInvalidPriority)
+	}
+	err := normalizeTagFilter(&f)
+	if err != nil {
+		return Domain.TaskPage{}, err
+	}
+	f.VisibleTo = primitive.NilObjectID
+	f.VisibleToName = ""
+	f.MemberOf = nil
+	if !actor.IsAdmin() {
+		if actor.UserID.IsZero() {
+			return Domain.TaskPage{Tasks: []Domain.Task{}}, nil
+		}
+		f.VisibleTo = actor.UserID
+		f.VisibleToName = actor.Username
+		if f.MemberOf, err = t.memberOf(ctx, actor); err != nil {
+			return Domain.TaskPage{}, err
+		}
+	}
+	page, err := t.repo.FindAll(ctx, f)
+	if err != nil {
+		return Domain.TaskPage{}, err
+	}
+	if err := t.annotate(ctx, page.Tasks); err != nil {
+		return Domain.TaskPage{}, err
+	}
+	return page, nil
+}
+
+// GetByID returns an empty task when it does not exist, is in the trash or
+// is not visible to the actor.
+func (t *TaskUsecase) GetByID(ctx context.Context, actor Domain.Actor, id string) (Domain.Task, error) {
+	task, err := t.visible(ctx, actor, id)
+	if err != nil || task.ID.IsZero() {
+		return task, err
+	}
+	return t.annotated(ctx, task)
+}
+
+// annotate fills in the computed fields of tasks in place.
+func (t *TaskUsecase) annotate(ctx context.Context, tasks []Domain.Task) error {
+	if err := t.countSubtasks(ctx, tasks); err != nil {
+		return err
+	}
+	return t.markBlocked(ctx, tasks)
+}
+
+// annotated returns task with its computed fields filled in.
+func (t *TaskUsecase) annotated(ctx context.Context, task Domain.Task) (Domain.Task, error) {
+	tasks := []Domain.Task{task}
+	if err := t.annotate(ctx, tasks); err != nil {
+		return Domain.Task{}, err
+	}
+	return tasks[0], nil
 }
 
-func (t *TaskUsecase) Create(ctx context.Context, input Domain.Task) (Domain.Task, error) {
+// visible is GetByID without the computed fields.
+func (t *TaskUsecase) visible(ctx context.Context, actor Domain.Actor, id string) (Domain.Task, error) {
+	task, _, err := t.load(ctx, actor, id)
+	return task, err
+}
+
+// load returns a live task visible to the actor together with its project.
+func (t *TaskUsecase) load(ctx context.Context, actor Domain.Actor, id string) (Domain.Task, Domain.Project, error) {
+	task, err := t.repo.FindByID(ctx, id)
+	if err != nil || task.ID.IsZero() || task.IsDeleted() {
+		return Domain.Task{}, Domain.Project{}, err
+	}
+	p, err := t.projectOf(ctx, task, nil)
+	if err != nil {
+		return Domain.Task{}, Domain.Project{}, err
+	}
+	if !task.CanView(actor, p) {
+		return Domain.Task{}, Domain.Project{}, nil
+	}
+	return task, p, nil
+}
+
+// Overdue lists tasks whose due date has passed and that are not done.
+func (t *TaskUsecase) Overdue(ctx context.Context, actor Domain.Actor, f Domain.TaskFilter) (Domain.TaskPage, error) {
+	f.Overdue = true
+	if f.SortBy == "" {
+		f.SortBy = Domain.SortByDueDate
+	}
+	return t.List(ctx, actor, f)
+}
+
+// Location returns the actor's configured time zone, defaulting to UTC.
+func (t *TaskUsecase) Location(ctx context.Context, actor Domain.Actor) (*time.Location, error) {
+	if actor.Username == "" {
+		return time.UTC, nil
+	}
+	u, err := t.users.FindByUsername(ctx, actor.Username)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := LoadTimezone(u.Timezone)
+	if err != nil {
+		// a stale zone name should not block task writes
+		return time.UTC, nil
+	}
+	return loc, nil
+}
+
+// toTask validates input and converts it to a task entity.
+func (t *TaskUsecase) toTask(ctx context.Context, actor Domain.Actor, input Domain.TaskInput) (Domain.Task, error) {
+	task := Domain.Task{
+		Title:       input.Title,
+		Description: input.Description,
+		Status:      input.Status,
+		SharedWith:  input.SharedWith,
+		ParentID:    input.ParentID,
+	}
+	priority, err := checkPriority(input.Priority)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	task.Priority = priority
+	task.PriorityRank = Domain.PriorityRank(priority)
+	tags, err := t.checkTags(ctx, input.Tags)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	task.Tags = tags
+	if input.DueDate != "" {
+		loc, err := t.Location(ctx, actor)
+		if err != nil {
+			return Domain.Task{}, err
+		}
+		due, err := ParseDueDate(input.DueDate, loc, true)
+		if err != nil {
+			return Domain.Task{}, err
+		}
+		task.DueDate = &due
+	}
+	return task, nil
+}
+
+// Create records the actor as creator and owner of the new task, which goes
+// into the actor's personal project unless input names another one.
+func (t *TaskUsecase) Create(ctx context.Context, actor Domain.Actor, input Domain.TaskInput) (Domain.Task, error) {
 	if input.Title == "" {
-		return Domain.Task{}, errors.New("title required")
+		return Domain.Task{}, Domain.ErrTitleRequired
+	}
+	if actor.UserID.IsZero() {
+		return Domain.Task{}, Domain.ErrForbidden
+	}
+	task, err := t.toTask(ctx, actor, input)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	if err := t.checkParent(ctx, actor, primitive.NilObjectID, task.ParentID); err != nil {
+		return Domain.Task{}, err
 	}
-	return t.repo.Create(ctx, input)
+	if task.Recurrence, err = t.recurrenceFor(ctx, actor, Domain.Task{}, input.Recurrence, task.DueDate); err != nil {
+		return Domain.Task{}, err
+	}
+	// new tasks enter the workflow at its initial state; later states are
+	// only reached through transitions
+	switch {
+	case task.Status == "":
+		task.Status = t.workflow.Initial
+	case !t.workflow.IsState(task.Status):
+		return Domain.Task{}, fmt.Errorf("%w %q", Domain.ErrUnknownStatus, task.Status)
+	case task.Status != t.workflow.Initial:
+		return Domain.Task{}, &Domain.TransitionError{To: task.Status, Allowed: []string{t.workflow.Initial}}
+	}
+	if task.ProjectID, err = t.targetProject(ctx, actor, input.ProjectID); err != nil {
+		return Domain.Task{}, err
+	}
+	task.CreatorID = actor.UserID
+	task.OwnerID = actor.UserID
+	task.CreatedBy = actor.Username
+	created, err := t.repo.Create(ctx, task)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	t.recordCommitted(ctx, actor, Domain.HistoryCreated, Domain.Task{}, created)
+	return created, nil
+}
+
+// editable loads a task the actor is allowed to modify. It returns an empty
+// task when the task does not exist or is hidden from the actor, and
+// ErrVersionMismatch when ifVersion is set and the task is at another version.
+func (t *TaskUsecase) editable(ctx context.Context, actor Domain.Actor, id string, ifVersion *int64) (Domain.Task, error) {
+	existing, p, err := t.load(ctx, actor, id)
+	if err != nil || existing.ID.IsZero() {
+		return Domain.Task{}, err
+	}
+	if !existing.CanModify(actor, p) {
+		return Domain.Task{}, Domain.ErrForbidden
+	}
+	if ifVersion != nil && *ifVersion != existing.Version {
+		return Domain.Task{}, Domain.ErrVersionMismatch
+	}
+	return existing, nil
+}
+
+// raced translates a write that lost to a concurrent change. It stays
+// ErrVersionMismatch only when the client asked for the version with
+// ifVersion; a version read by the usecase itself yields ErrEditConflict.
+func raced(err error, ifVersion *int64) error {
+	if ifVersion == nil && errors.Is(err, Domain.ErrVersionMismatch) {
+		return Domain.ErrEditConflict
+	}
+	return err
+}
+
+// Update replaces every client-editable field of the task, validating input
+// like Create. Optional fields left out of input are cleared and an omitted
+// priority is reset to the default; an omitted status keeps the current one
+// since status only moves through the workflow, and an omitted project keeps
+// the task where it is. The write only applies to the version that was
+// validated, so a concurrent change yields ErrVersionMismatch, or
+// ErrEditConflict when ifVersion is nil.
+func (t *TaskUsecase) Update(ctx context.Context, actor Domain.Actor, id string, input Domain.TaskInput, ifVersion *int64) (Domain.Task, error) {
+	if input.Title == "" {
+		return Domain.Task{}, Domain.ErrTitleRequired
+	}
+	existing, err := t.editable(ctx, actor, id, ifVersion)
+	if err != nil || existing.ID.IsZero() {
+		return Domain.Task{}, err
+	}
+	task, err := t.toTask(ctx, actor, input)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	changes := Domain.TaskChanges{
+		Set:       map[string]interface{}{"title": task.Title},
+		IfVersion: &existing.Version,
+		UpdatedBy: actor.Username,
+	}
+	setOrUnset(&changes, "description", task.Description, task.Description == "")
+	setOrUnset(&changes, "due_date", task.DueDate, task.DueDate == nil)
+	setOrUnset(&changes, "shared_with", task.SharedWith, len(task.SharedWith) == 0)
+	setOrUnset(&changes, "tags", task.Tags, len(task.Tags) == 0)
+	setPriority(&changes, task.Priority)
+	if err := t.moveTo(ctx, actor, existing, input.ProjectID, &changes); err != nil {
+		return Domain.Task{}, err
+	}
+	if err := t.checkParent(ctx, actor, existing.ID, task.ParentID); err != nil {
+		return Domain.Task{}, err
+	}
+	setOrUnset(&changes, "parent_id", task.ParentID, task.ParentID.IsZero())
+	rec, err := t.recurrenceFor(ctx, actor, existing, input.Recurrence, task.DueDate)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	setOrUnset(&changes, "recurrence", rec, rec == nil)
+	if task.Status != "" {
+		if err := t.checkStatusChange(ctx, existing, task.Status); err != nil {
+			return Domain.Task{}, err
+		}
+		changes.Set["status"] = task.Status
+	}
+	updated, err := t.apply(ctx, actor, Domain.HistoryUpdated, existing, changes)
+	return updated, raced(err, ifVersion)
+}
+
+// apply writes changes to an existing task and records them in its history
+// under action.
+func (t *TaskUsecase) apply(ctx context.Context, actor Domain.Actor, action string, existing Domain.Task, changes Domain.TaskChanges) (Domain.Task, error) {
+	updated, err := t.repo.Update(ctx, existing.ID.Hex(), changes)
+	if err != nil || updated.ID.IsZero() {
+		return updated, err
+	}
+	t.recordCommitted(ctx, actor, action, existing, updated)
+	t.completed(ctx, actor, existing, updated)
+	return t.annotated(ctx, updated)
+}
+
+// Patch applies an RFC 7396 JSON Merge Patch. Members set to null are
+// removed from the task, except priority which falls back to the default;
+// title, status and project_id cannot be removed. An empty patch changes
+// nothing and returns the task as it is.
+func (t *TaskUsecase) Patch(ctx context.Context, actor Domain.Actor, id string, patch map[string]json.RawMessage, ifVersion *int64) (Domain.Task, error) {
+	existing, err := t.editable(ctx, actor, id, ifVersion)
+	if err != nil || existing.ID.IsZero() {
+		return Domain.Task{}, err
+	}
+	if len(patch) == 0 {
+		return t.annotated(ctx, existing)
+	}
+	changes := Domain.TaskChanges{
+		Set:       map[string]interface{}{},
+		IfVersion: &existing.Version,
+		UpdatedBy: actor.Username,
+	}
+	var rule *string
+	for field, raw := range patch {
+		isNull := string(raw) == "null"
+		switch field {
+		case "title":
+			var title string
+			if isNull || json.Unmarshal(raw, &title) != nil || title == "" {
+				return Domain.Task{}, Domain.ErrTitleRequired
+			}
+			changes.Set["title"] = title
+		case "description":
+			var desc string
+			if !isNull && json.Unmarshal(raw, &desc) != nil {
+				return Domain.Task{}, fmt.Errorf("%w: description must be a string", Domain.ErrInvalidPatch)
+			}
+			setOrUnset(&changes, "description", desc, desc == "")
+		case "due_date":
+			var value string
+			if !isNull && json.Unmarshal(raw, &value) != nil {
+				return Domain.Task{}, Domain.ErrInvalidDueDate
+			}
+			if value == "" {
+				changes.Unset = append(changes.Unset, "due_date")
+				continue
+			}
+			loc, err := t.Location(ctx, actor)
+			if err != nil {
+				return Domain.Task{}, err
+			}
+			due, err := ParseDueDate(value, loc, true)
+			if err != nil {
+				return Domain.Task{}, err
+			}
+			changes.Set["due_date"] = due
+		case "status":
+			var status string
+			if isNull || json.Unmarshal(raw, &status) != nil {
+				return Domain.Task{}, fmt.Errorf("%w: status cannot be removed", Domain.ErrInvalidPatch)
+			}
+			if err := t.checkStatusChange(ctx, existing, status); err != nil {
+				return Domain.Task{}, err
+			}
+			changes.Set["status"] = status
+		case "priority":
+			var value string
+			if !isNull && json.Unmarshal(raw, &value) != nil {
+				return Domain.Task{}, fmt.Errorf("%w: priority must be a string", Domain.ErrInvalidPatch)
+			}
+			priority, err := checkPriority(value)
+			if err != nil {
+				return Domain.Task{}, err
+			}
+			setPriority(&changes, priority)
+		case "shared_with":
+			var ids []primitive.ObjectID
+			if !isNull && json.Unmarshal(raw, &ids) != nil {
+				return Domain.Task{}, fmt.Errorf("%w: shared_with must be a list of user ids", Domain.ErrInvalidPatch)
+			}
+			setOrUnset(&changes, "shared_with", ids, len(ids) == 0)
+		case "parent_id":
+			var parent primitive.ObjectID
+			if !isNull && json.Unmarshal(raw, &parent) != nil {
+				return Domain.Task{}, fmt.Errorf("%w: parent_id must be a task id", Domain.ErrInvalidPatch)
+			}
+			if err := t.checkParent(ctx, actor, existing.ID, parent); err != nil {
+				return Domain.Task{}, err
+			}
+			setOrUnset(&changes, "parent_id", parent, parent.IsZero())
+		case "recurrence":
+			var value string
+			if !isNull && json.Unmarshal(raw, &value) != nil {
+				return Domain.Task{}, fmt.Errorf("%w: recurrence must be a string", Domain.ErrInvalidPatch)
+			}
+			rule = &value
+		case "project_id":
+			var project primitive.ObjectID
+			if isNull || json.Unmarshal(raw, &project) != nil {
+				return Domain.Task{}, fmt.Errorf("%w: project_id must be a project id", Domain.ErrInvalidPatch)
+			}
+			if err := t.moveTo(ctx, actor, existing, project, &changes); err != nil {
+				return Domain.Task{}, err
+			}
+		case "tags":
+			var names []string
+			if !isNull && json.Unmarshal(raw, &names) != nil {
+				return Domain.Task{}, fmt.Errorf("%w: tags must be a list of names", Domain.ErrInvalidPatch)
+			}
+			tags, err := t.checkTags(ctx, names)
+			if err != nil {
+				return Domain.Task{}, err
+			}
+			setOrUnset(&changes, "tags", tags, len(tags) == 0)
+		default:
+			return Domain.Task{}, fmt.Errorf("%w: unknown field %q", Domain.ErrInvalidPatch, field)
+		}
+	}
+	// recurrence depends on the patched due date, so it is resolved last
+	due := existing.DueDate
+	if v, ok := changes.Set["due_date"].(time.Time); ok {
+		due = &v
+	} else if containsString(changes.Unset, "due_date") {
+		due = nil
+	}
+	if rule != nil {
+		rec, err := t.recurrenceFor(ctx, actor, existing, *rule, due)
+		if err != nil {
+			return Domain.Task{}, err
+		}
+		setOrUnset(&changes, "recurrence", rec, rec == nil)
+	} else if existing.Recurrence != nil && due == nil {
+		return Domain.Task{}, fmt.Errorf("%w: recurring tasks need a due date", Domain.ErrInvalidRecurrence)
+	}
+	updated, err := t.apply(ctx, actor, Domain.HistoryUpdated, existing, changes)
+	return updated, raced(err, ifVersion)
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// setOrUnset records field in changes, unsetting it when empty is true.
+func setOrUnset(changes *Domain.TaskChanges, field string, value interface{}, empty bool) {
+	if empty {
+		changes.Unset = append(changes.Unset, field)
+		return
+	}
+	changes.Set[field] = value
+}
+
+// Delete moves a task to the trash. Project owners and editors can delete
+// the project's tasks and admins any task; trashed tasks are purged after
+// the retention period. The write is only conditional on the version when
+// ifVersion is given, and never applies to a task already in the trash, so
+// of two racing deletes the second finds nothing and leaves the first's
+// timestamp and history entry alone.
+func (t *TaskUsecase) Delete(ctx context.Context, actor Domain.Actor, id string, ifVersion *int64) (bool, error) {
+	existing, err := t.editable(ctx, actor, id, ifVersion)
+	if err != nil || existing.ID.IsZero() {
+		return false, err
+	}
+	deleted, err := t.apply(ctx, actor, Domain.HistoryDeleted, existing, Domain.TaskChanges{
+		Set: map[string]interface{}{
+			"deleted_at": time.Now().UTC().Truncate(time.Millisecond),
+			"deleted_by": actor.Username,
+		},
+		IfVersion: ifVersion,
+		IfLive:    true,
+		UpdatedBy: actor.Username,
+	})
+	return !deleted.ID.IsZero(), err
 }
 
-func (t *TaskUsecase) Update(ctx context.Context, id string, input Domain.Task) (Domain.Task, error) {
-	return t.repo.Update(ctx, id, input)
+// Assign adds an existing user to the task's assignees.
+func (t *TaskUsecase) Assign(ctx context.Context, actor Domain.Actor, id, username string) (Domain.Task, error) {
+	existing, err := t.editable(ctx, actor, id, nil)
+	if err != nil || existing.ID.IsZero() {
+		return Domain.Task{}, err
+	}
+	u, err := t.users.FindByUsername(ctx, username)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	if u.Username == "" {
+		return Domain.Task{}, Domain.ErrUserNotFound
+	}
+	return t.apply(ctx, actor, Domain.HistoryUpdated, existing, Domain.TaskChanges{
+		AddToSet:  map[string]interface{}{"assignees": u.Username},
+		UpdatedBy: actor.Username,
+	})
 }
 
-func (t *TaskUsecase) Delete(ctx context.Context, id string) (bool, error) {
-	return t.repo.Delete(ctx, id)
+// Unassign removes a user from the task's assignees.
+func (t *TaskUsecase) Unassign(ctx context.Context, actor Domain.Actor, id, username string) (Domain.Task, error) {
+	existing, err := t.editable(ctx, actor, id, nil)
+	if err != nil || existing.ID.IsZero() {
+		return Domain.Task{}, err
+	}
+	if !existing.IsAssigned(username) {
+		return Domain.Task{}, Domain.ErrUserNotFound
+	}
+	return t.apply(ctx, actor, Domain.HistoryUpdated, existing, Domain.TaskChanges{
+		Pull:      map[string]interface{}{"assignees": username},
+		UpdatedBy: actor.Username,
+	})
 }
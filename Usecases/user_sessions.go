@@ -0,0 +1,137 @@
+package Usecases
+
+import (
+	"context"
+	"time"
+
+	"task_manager1/Domain"
+	"task_manager1/Infrastructure/security"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultRefreshTTL is how long a refresh token stays valid when unused.
+const DefaultRefreshTTL = 30 * 24 * time.Hour
+
+// SetRefreshTTL changes how long refresh tokens stay valid.
+func (u *UserUsecase) SetRefreshTTL(ttl time.Duration) {
+	u.refreshTTL = ttl
+}
+
+// StartSession issues the first refresh token of a new family for a user
+// who just signed in.
+func (u *UserUsecase) StartSession(ctx context.Context, user Domain.User) (string, error) {
+	return u.issueRefreshToken(ctx, user, primitive.NewObjectID())
+}
+
+// Refresh exchanges a refresh token for the next token of its family and
+// returns the user it was issued to. A token can be exchanged once:
+// presenting it again means it leaked, so the whole family is revoked and
+// ErrRefreshTokenReused is returned. The successor is stored before the
+// token is marked used, so a failure in between leaves the session usable.
+func (u *UserUsecase) Refresh(ctx context.Context, token string) (Domain.User, string, error) {
+	if token == "" {
+		return Domain.User{}, "", Domain.ErrInvalidRefreshToken
+	}
+	stored, err := u.tokens.FindByHash(Domain.AllTenants(ctx), security.HashToken(token))
+	if err != nil {
+		return Domain.User{}, "", err
+	}
+	if stored.ID.IsZero() {
+		return Domain.User{}, "", Domain.ErrInvalidRefreshToken
+	}
+	ctx = Domain.WithTenant(ctx, stored.TenantID)
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	switch {
+	case stored.RevokedAt != nil:
+		return Domain.User{}, "", Domain.ErrInvalidRefreshToken
+	case stored.UsedAt != nil:
+		return Domain.User{}, "", u.revokeFamily(ctx, stored, now)
+	case !stored.Usable(now):
+		return Domain.User{}, "", Domain.ErrInvalidRefreshToken
+	}
+
+	// roles may have changed since the last refresh
+	user, err := u.repo.FindByUsername(ctx, stored.Username)
+	if err != nil {
+		return Domain.User{}, "", err
+	}
+	if user.ID != stored.UserID || user.Disabled {
+		return Domain.User{}, "", Domain.ErrInvalidRefreshToken
+	}
+	next, err := u.issueRefreshToken(ctx, user, stored.FamilyID)
+	if err != nil {
+		return Domain.User{}, "", err
+	}
+	marked, err := u.tokens.MarkUsed(ctx, stored.ID, now)
+	if err != nil {
+		return Domain.User{}, "", err
+	}
+	if !marked {
+		// another request exchanged it first; revoking the family also
+		// revokes the successor just issued
+		return Domain.User{}, "", u.revokeFamily(ctx, stored, now)
+	}
+	user.PasswordHash = ""
+	return user, next, nil
+}
+
+// EndSession revokes the refresh token family of token when it belongs to
+// userID. Tokens of other users are ignored rather than reported.
+func (u *UserUsecase) EndSession(ctx context.Context, userID primitive.ObjectID, token string) error {
+	if token == "" {
+		return nil
+	}
+	stored, err := u.tokens.FindByHash(ctx, security.HashToken(token))
+	if err != nil || stored.UserID != userID {
+		return err
+	}
+	_, err = u.tokens.RevokeFamily(ctx, stored.FamilyID, time.Now().UTC().Truncate(time.Millisecond))
+	return err
+}
+
+// RevokeSessions revokes every refresh token of a user of the organisation
+// ctx is scoped to and returns the user, so the caller can also block the
+// user's access tokens.
+func (u *UserUsecase) RevokeSessions(ctx context.Context, username string) (Domain.User, error) {
+	user, err := u.repo.FindByUsername(ctx, username)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	if user.Username == "" {
+		return Domain.User{}, Domain.ErrUserNotFound
+	}
+	if _, err := u.tokens.RevokeUser(ctx, user.ID, time.Now().UTC().Truncate(time.Millisecond)); err != nil {
+		return Domain.User{}, err
+	}
+	user.PasswordHash = ""
+	return user, nil
+}
+
+func (u *UserUsecase) revokeFamily(ctx context.Context, stored Domain.RefreshToken, now time.Time) error {
+	if _, err := u.tokens.RevokeFamily(ctx, stored.FamilyID, now); err != nil {
+		return err
+	}
+	return Domain.ErrRefreshTokenReused
+}
+
+func (u *UserUsecase) issueRefreshToken(ctx context.Context, user Domain.User, family primitive.ObjectID) (string, error) {
+	token, err := security.NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	_, err = u.tokens.Create(Domain.WithTenant(ctx, user.TenantID), Domain.RefreshToken{
+		TenantID:  user.TenantID,
+		UserID:    user.ID,
+		Username:  user.Username,
+		FamilyID:  family,
+		Hash:      security.HashToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(u.refreshTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
@@ -3,6 +3,7 @@ package Usecases
 import (
 	"context"
 	"errors"
+	"time"
 
 	"task_manager1/Domain"
 	"task_manager1/Infrastructure/security"
@@ -11,19 +12,35 @@ import (
 
 // UserUsecase holds dependencies for user business rules.
 type UserUsecase struct {
-	repo Repositories.UserRepository
-	pw   *security.PasswordService
+	repo       Repositories.UserRepository
+	orgs       Repositories.OrganizationRepository
+	tokens     Repositories.RefreshTokenRepository
+	pw         *security.PasswordService
+	refreshTTL time.Duration
 }
 
-func NewUserUsecase(r Repositories.UserRepository, pw *security.PasswordService) *UserUsecase {
-	return &UserUsecase{repo: r, pw: pw}
+func NewUserUsecase(r Repositories.UserRepository, orgs Repositories.OrganizationRepository, tokens Repositories.RefreshTokenRepository, pw *security.PasswordService) *UserUsecase {
+	return &UserUsecase{repo: r, orgs: orgs, tokens: tokens, pw: pw, refreshTTL: DefaultRefreshTTL}
 }
 
-// Register creates a new user. First created user becomes admin.
-func (u *UserUsecase) Register(ctx context.Context, username, password string) (Domain.User, error) {
+// Register creates a new organisation and its first user, who becomes the
+// organisation's admin.
+func (u *UserUsecase) Register(ctx context.Context, username, password, organization string) (Domain.User, error) {
 	if username == "" || password == "" {
 		return Domain.User{}, errors.New("username and password required")
 	}
+	name, err := Domain.NormalizeOrganizationName(organization)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	// usernames are unique across organisations
+	existing, err := u.repo.FindByUsername(Domain.AllTenants(ctx), username)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	if existing.Username != "" {
+		return Domain.User{}, errors.New("username already exists")
+	}
 
 	// hash password
 	hash, err := u.pw.HashPassword(password)
@@ -31,27 +48,59 @@ func (u *UserUsecase) Register(ctx context.Context, username, password string) (
 		return Domain.User{}, err
 	}
 
-	user := Domain.User{
+	org, err := u.orgs.Create(ctx, Domain.Organization{Name: name, CreatedBy: username})
+	if err != nil {
+		return Domain.User{}, err
+	}
+	user, err := u.repo.Create(Domain.WithTenant(ctx, org.ID), Domain.User{
 		Username:     username,
 		PasswordHash: hash,
-		Role:         "user",
+		Role:         "admin",
+	})
+	if err != nil {
+		// do not leave an organisation nobody can sign in to
+		_, _ = u.orgs.Delete(ctx, org.ID)
+		return Domain.User{}, err
 	}
+	return user, nil
+}
 
-	// If first user → assign admin role
-	cnt, err := u.repo.Count(ctx)
+// CreateMember adds a user to the organisation ctx is scoped to.
+func (u *UserUsecase) CreateMember(ctx context.Context, username, password string) (Domain.User, error) {
+	if username == "" || password == "" {
+		return Domain.User{}, errors.New("username and password required")
+	}
+	hash, err := u.pw.HashPassword(password)
 	if err != nil {
 		return Domain.User{}, err
 	}
-	if cnt == 0 {
-		user.Role = "admin"
-	}
+	return u.repo.Create(ctx, Domain.User{
+		Username:     username,
+		PasswordHash: hash,
+		Role:         "user",
+	})
+}
 
-	return u.repo.Create(ctx, user)
+// Organization returns the organisation ctx is scoped to.
+func (u *UserUsecase) Organization(ctx context.Context) (Domain.Organization, error) {
+	id, all, ok := Domain.TenantFromContext(ctx)
+	if !ok || all {
+		return Domain.Organization{}, Domain.ErrNoTenant
+	}
+	org, err := u.orgs.FindByID(ctx, id)
+	if err != nil {
+		return Domain.Organization{}, err
+	}
+	if org.ID.IsZero() {
+		return Domain.Organization{}, Domain.ErrOrganizationNotFound
+	}
+	return org, nil
 }
 
 // Authenticate checks username + password and returns user without hash.
+// The login names the user's organisation, so the lookup spans all of them.
 func (u *UserUsecase) Authenticate(ctx context.Context, username, password string) (Domain.User, error) {
-	found, err := u.repo.FindByUsername(ctx, username)
+	found, err := u.repo.FindByUsername(Domain.AllTenants(ctx), username)
 	if err != nil {
 		return Domain.User{}, err
 	}
@@ -64,13 +113,16 @@ func (u *UserUsecase) Authenticate(ctx context.Context, username, password strin
 	if !u.pw.ComparePassword(found.PasswordHash, password) {
 		return Domain.User{}, errors.New("invalid credentials")
 	}
+	if found.Disabled {
+		return Domain.User{}, errors.New("account disabled")
+	}
 
 	// Never return password hash
 	found.PasswordHash = ""
 	return found, nil
 }
 
-// Promote makes a user an admin.
+// Promote makes a user an admin of the organisation ctx is scoped to.
 func (u *UserUsecase) Promote(ctx context.Context, username string) (Domain.User, error) {
 	updated, err := u.repo.PromoteToAdmin(ctx, username)
 	if err != nil {
@@ -78,3 +130,36 @@ func (u *UserUsecase) Promote(ctx context.Context, username string) (Domain.User
 	}
 	return updated, nil
 }
+
+// Demote takes the admin role away from a user of the organisation ctx is
+// scoped to. Admins cannot demote themselves.
+func (u *UserUsecase) Demote(ctx context.Context, actor Domain.Actor, username string) (Domain.User, error) {
+	if username == actor.Username {
+		return Domain.User{}, Domain.ErrOwnAccount
+	}
+	return u.repo.DemoteToUser(ctx, username)
+}
+
+// SetDisabled disables or re-enables a user of the organisation ctx is
+// scoped to. Disabling also revokes the user's refresh tokens.
+func (u *UserUsecase) SetDisabled(ctx context.Context, actor Domain.Actor, username string, disabled bool) (Domain.User, error) {
+	if username == actor.Username {
+		return Domain.User{}, Domain.ErrOwnAccount
+	}
+	updated, err := u.repo.SetDisabled(ctx, username, disabled)
+	if err != nil || updated.Username == "" || !disabled {
+		return updated, err
+	}
+	if _, err := u.tokens.RevokeUser(ctx, updated.ID, time.Now().UTC().Truncate(time.Millisecond)); err != nil {
+		return Domain.User{}, err
+	}
+	return updated, nil
+}
+
+// SetTimezone stores the IANA time zone used to read the user's date-only due dates.
+func (u *UserUsecase) SetTimezone(ctx context.Context, username, timezone string) (Domain.User, error) {
+	if _, err := LoadTimezone(timezone); err != nil {
+		return Domain.User{}, err
+	}
+	return u.repo.SetTimezone(ctx, username, timezone)
+}